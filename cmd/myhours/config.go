@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/msepp/myhours"
+)
+
+// config defines the contents of the user configuration file.
+type config struct {
+	// Keys overrides key bindings per action.
+	Keys myhours.KeyBindings `json:"keys"`
+	// Theme is the name of a built-in theme to use.
+	Theme string `json:"theme"`
+	// ThemeFile is a JSON file with theme customizations. Values are applied on
+	// top of the selected built-in theme.
+	ThemeFile string `json:"themeFile"`
+	// Glyphs overrides the glyph set of the theme. Use "plain" for terminals
+	// without Nerd Fonts.
+	Glyphs string `json:"glyphs"`
+}
+
+// theme resolves the theme selected in configuration.
+func (c config) theme() (myhours.Theme, error) {
+	name := c.Theme
+	if name == "" {
+		name = "default"
+	}
+	theme, ok := myhours.BuiltinTheme(name)
+	if !ok {
+		return theme, fmt.Errorf("unknown theme %q, available: %s", name, strings.Join(myhours.ThemeNames(), ", "))
+	}
+	if c.ThemeFile != "" {
+		data, err := os.ReadFile(c.ThemeFile)
+		if err != nil {
+			return theme, fmt.Errorf("os.ReadFile: %w", err)
+		}
+		if err = json.Unmarshal(data, &theme); err != nil {
+			return theme, fmt.Errorf("json.Unmarshal: %w", err)
+		}
+	}
+	if c.Glyphs != "" {
+		theme.Glyphs = c.Glyphs
+	}
+	switch theme.Glyphs {
+	case myhours.GlyphsNerdFont, myhours.GlyphsPlain:
+	default:
+		return theme, fmt.Errorf("unknown glyph set %q", theme.Glyphs)
+	}
+	return theme, nil
+}
+
+// loadConfig reads user configuration from given file. If the file does not
+// exist, an empty configuration is returned.
+func loadConfig(file string) (config, error) {
+	var cfg config
+	data, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return cfg, nil
+		}
+		return cfg, fmt.Errorf("os.ReadFile: %w", err)
+	}
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if err = cfg.Keys.Validate(); err != nil {
+		return cfg, fmt.Errorf("keys: %w", err)
+	}
+	return cfg, nil
+}
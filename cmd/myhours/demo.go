@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/msepp/myhours"
+	"github.com/msepp/myhours/database/memory"
+)
+
+// demoWeeks is the number of weeks of records generated for the demo.
+const demoWeeks = 8
+
+// Categories of the default database, used in demo records.
+const (
+	demoWork     = 2
+	demoPersonal = 3
+)
+
+var (
+	demoWorkNotes     = []string{"code review", "PROJ-101 login flow", "PROJ-117 report export", "planning", "email", "1:1"}
+	demoPersonalNotes = []string{"guitar", "side project", "reading"}
+)
+
+// demoDatabase returns an in-memory database with generated records for the
+// weeks before now, and a work record running since an hour ago.
+func demoDatabase(ctx context.Context, now time.Time) (myhours.Database, error) {
+	db := memory.NewMemory()
+	// fixed seed, so that the demo looks the same every time.
+	records := demoRecords(now.Add(-time.Hour), rand.New(rand.NewPCG(1, 2)))
+	if _, err := db.ImportRecords(ctx, records); err != nil {
+		return nil, fmt.Errorf("db.ImportRecords: %w", err)
+	}
+	if _, err := db.StartRecord(ctx, now.Add(-time.Hour), demoWork, demoWorkNotes[0]); err != nil {
+		return nil, fmt.Errorf("db.StartRecord: %w", err)
+	}
+	settings := map[myhours.Setting]string{
+		myhours.SettingDefaultCategory: fmt.Sprint(demoWork),
+		myhours.SettingPinnedTasks:     fmt.Sprintf(`[{"category":%d,"notes":"standup"}]`, demoWork),
+	}
+	for key, value := range settings {
+		if err := db.UpdateSetting(ctx, key, value); err != nil {
+			return nil, fmt.Errorf("db.UpdateSetting: %w", err)
+		}
+	}
+	return db, nil
+}
+
+// demoRecords generates finished records for the days before the day of
+// until: a standup and a few tasks on workdays, with a lunch break, and now
+// and then some personal time in the evening.
+func demoRecords(until time.Time, rng *rand.Rand) []myhours.Record {
+	var (
+		records []myhours.Record
+		today   = time.Date(until.Year(), until.Month(), until.Day(), 0, 0, 0, 0, time.Local)
+		minutes = func(lo, hi int) time.Duration { return time.Duration(lo+rng.IntN(hi-lo+1)) * time.Minute }
+	)
+	for day := today.AddDate(0, 0, -7*demoWeeks); day.Before(today); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			start := day.Add(8*time.Hour + minutes(30, 60))
+			records = append(records, myhours.Record{Start: start, End: start.Add(15 * time.Minute), CategoryID: demoWork, Notes: "standup"})
+			start = start.Add(15 * time.Minute)
+			for i := range 2 + rng.IntN(3) {
+				record := myhours.Record{
+					Start:      start,
+					End:        start.Add(minutes(60, 150)),
+					CategoryID: demoWork,
+					Notes:      demoWorkNotes[rng.IntN(len(demoWorkNotes))],
+					Pomodoros:  rng.IntN(4),
+				}
+				if i == 1 {
+					lunch := record.Start.Add(minutes(20, 40))
+					record.Breaks = []myhours.Break{{Start: lunch, End: lunch.Add(minutes(30, 45))}}
+					record.End = record.End.Add(record.BreakDuration(record.End))
+				}
+				records = append(records, record)
+				start = record.End.Add(minutes(5, 20))
+			}
+		}
+		if rng.IntN(3) == 0 {
+			start := day.Add(19*time.Hour + minutes(0, 60))
+			records = append(records, myhours.Record{
+				Start:      start,
+				End:        start.Add(minutes(30, 120)),
+				CategoryID: demoPersonal,
+				Notes:      demoPersonalNotes[rng.IntN(len(demoPersonalNotes))],
+			})
+		}
+	}
+	return records
+}
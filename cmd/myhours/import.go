@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/msepp/myhours"
+)
+
+// importBatchSize is the number of records imported in one transaction.
+const importBatchSize = 1000
+
+// errInvalidLine is returned for import lines that can't be parsed.
+var errInvalidLine = errors.New("invalid line")
+
+// readImport iterates the records of import data read from r, one record per
+// line. Empty lines and lines starting with # are skipped. Iteration stops at
+// the first line that can't be parsed.
+func readImport(r io.Reader) iter.Seq2[myhours.Record, error] {
+	return func(yield func(myhours.Record, error) bool) {
+		scanner := bufio.NewScanner(r)
+		for lineNo := 1; scanner.Scan(); lineNo++ {
+			line := strings.TrimSpace(scanner.Text())
+			// skip empty lines and comments
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			record, err := parseImportLine(line)
+			if err != nil {
+				yield(myhours.Record{}, fmt.Errorf("%w %d: %w", errInvalidLine, lineNo, err))
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			yield(myhours.Record{}, fmt.Errorf("scanner.Err: %w", err))
+		}
+	}
+}
+
+// parseImportLine parses a record from a line in format
+// '<start>,<duration>,<category>,<notes>'.
+func parseImportLine(line string) (myhours.Record, error) {
+	pcs := strings.SplitN(line, ",", 4)
+	if len(pcs) != 4 {
+		return myhours.Record{}, errors.New("expected 4 comma separated fields")
+	}
+	var (
+		record myhours.Record
+		err    error
+	)
+	if record.Start, err = time.Parse(time.RFC3339Nano, pcs[0]); err != nil {
+		return record, fmt.Errorf("start time: %w", err)
+	}
+	var duration time.Duration
+	if duration, err = time.ParseDuration(pcs[1]); err != nil {
+		return record, fmt.Errorf("duration: %w", err)
+	}
+	if record.CategoryID, err = strconv.ParseInt(pcs[2], 10, 64); err != nil {
+		return record, fmt.Errorf("category: %w", err)
+	}
+	record.Notes = strings.TrimSpace(pcs[3])
+	record.End = record.Start.Add(duration)
+	return record, nil
+}
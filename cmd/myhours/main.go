@@ -1,15 +1,16 @@
 package main
 
 import (
-	"bufio"
+	"context"
 	"database/sql"
 	_ "embed"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path"
-	"strconv"
 	"strings"
 	"time"
 
@@ -25,18 +26,38 @@ func main() {
 		os.Exit(1)
 	}
 	var (
-		logger     = slog.New(slog.DiscardHandler)
-		doImport   bool
-		verbose    bool
-		silent     bool
-		importFile = "import.txt"
-		dbLocation = path.Join(configDir, "my-hours-cli")
-		logDest    = "-"
-		dbFile     = path.Join(dbLocation, "database.db")
+		logger      = slog.New(slog.DiscardHandler)
+		doImport    bool
+		doSettings  bool
+		demo        bool
+		reportClock myhours.Clock
+		sets        []string
+		verbose     bool
+		silent      bool
+		importFile  = "import.txt"
+		dbLocation  = path.Join(configDir, "my-hours-cli")
+		logDest     = "-"
+		dbFile      = path.Join(dbLocation, "database.db")
+		configFile  = path.Join(dbLocation, "config.json")
 	)
 	flag.StringVar(&dbFile, "db", dbFile, "Database location")
+	flag.StringVar(&configFile, "config", configFile, "Configuration file location")
 	flag.BoolVar(&doImport, "import", doImport, "Run data import. -importFile selects import data location.")
-	flag.StringVar(&importFile, "importFile", importFile, "File with import data. Must contain lines in format '2006-01-02T15:04:05.999999999Z07:00,<duration>,categoryInt,notes'. Notes can not contain newlines.")
+	flag.StringVar(&importFile, "importFile", importFile, "File with import data. Must contain lines in format '2006-01-02T15:04:05.999999999Z07:00,<duration>,categoryInt,notes'. Notes can not contain newlines. Records are imported in batches, batches before an invalid line stay imported.")
+	flag.BoolVar(&doSettings, "settings", doSettings, "List settings with their current values.")
+	flag.Func("set", "Store a setting, in format 'key=value'. Can be repeated. See -settings for the available settings.", func(s string) error {
+		if !strings.Contains(s, "=") {
+			return errors.New("expected format key=value")
+		}
+		sets = append(sets, s)
+		return nil
+	})
+	flag.BoolVar(&demo, "demo", demo, "Run with generated sample data in memory. The database is not opened.")
+	flag.Func("asof", "Show reports as of given date, in format YYYY-MM-DD. Timer still runs in real time.", func(s string) (err error) {
+		asOf, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+		reportClock = myhours.FixedClock(asOf)
+		return err
+	})
 	flag.BoolVar(&verbose, "v", false, "Verbose output")
 	flag.BoolVar(&silent, "s", false, "Silence all log output")
 	flag.StringVar(&logDest, "log", logDest, "Log file destination. Use '-' for stderr")
@@ -56,14 +77,41 @@ func main() {
 			logger = slog.New(slog.NewTextHandler(lwr, &slog.HandlerOptions{Level: slog.LevelInfo}))
 		}
 	}
-	logger.Debug("opening database", slog.String("database", dbFile))
-	// Initialize the database
-	var dbConn *sql.DB
-	if dbConn, err = sqlite.InitiateSQLiteDatabase(dbFile); err != nil {
-		logger.Error("failed to open database", slog.String("error", err.Error()))
+	logger.Debug("loading configuration", slog.String("config", configFile))
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		logger.Error("failed to load configuration", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
-	db := sqlite.NewSQLite(dbConn, sqlite.Logger(logger))
+	var theme myhours.Theme
+	if theme, err = cfg.theme(); err != nil {
+		logger.Error("failed to load theme", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	// commands run without the application can be interrupted.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var db myhours.Database
+	if demo {
+		// demo data lives in memory only, the real database is left untouched.
+		logger.Debug("generating demo data")
+		if db, err = demoDatabase(ctx, time.Now()); err != nil {
+			logger.Error("failed to generate demo data", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+	} else {
+		logger.Debug("opening database", slog.String("database", dbFile))
+		// Initialize the database
+		var dbConn *sql.DB
+		if dbConn, err = sqlite.InitiateSQLiteDatabase(dbFile); err != nil {
+			logger.Error("failed to open database", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		db = sqlite.NewSQLite(dbConn, sqlite.Logger(logger))
+	}
+	if verbose {
+		db = myhours.LoggingDatabase(db, logger)
+	}
 	// If user wants to do import, do it now that we know we have a destination
 	// database ready.
 	if doImport {
@@ -73,52 +121,35 @@ func main() {
 			logger.Error("reading import file failed", slog.String("error", err.Error()))
 			os.Exit(1)
 		}
-		scanner := bufio.NewScanner(f)
-		var entries []myhours.Record
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			// skip empty lines and comments
-			if line == "" || strings.HasPrefix(line, "#") {
-				continue
-			}
-			pcs := strings.SplitN(line, ",", 4)
-			if len(pcs) != 4 {
-				logger.Error("invalid line format", slog.String("line", line))
-				os.Exit(2)
-			}
-			var entry myhours.Record
-			if entry.Start, err = time.Parse(time.RFC3339Nano, pcs[0]); err != nil {
-				logger.Error("invalid start time", slog.String("error", err.Error()))
-				os.Exit(2)
-			}
-			var duration time.Duration
-			if duration, err = time.ParseDuration(pcs[1]); err != nil {
-				logger.Error("invalid duration", slog.String("error", err.Error()))
+		var imported int
+		imported, err = myhours.ImportRecordsSeq(ctx, db, readImport(f), importBatchSize, func(imported int) {
+			logger.Info("importing", slog.Int("numberOfEntries", imported))
+		})
+		_ = f.Close()
+		if err != nil {
+			// batches before the failure stay imported.
+			logger.Error("failed to import records", slog.String("error", err.Error()), slog.Int("numberOfEntries", imported))
+			if errors.Is(err, errInvalidLine) {
 				os.Exit(2)
 			}
-			if entry.CategoryID, err = strconv.ParseInt(pcs[2], 10, 64); err != nil {
-				logger.Error("invalid category", slog.String("error", err.Error()))
-				os.Exit(2)
-			}
-			entry.Notes = strings.TrimSpace(pcs[3])
-			entry.End = entry.Start.Add(duration)
-			entries = append(entries, entry)
-		}
-		if scanner.Err() != nil {
-			logger.Error("reading import file failed", slog.String("error", scanner.Err().Error()))
 			os.Exit(1)
 		}
-		var result []int64
-		if result, err = db.ImportRecords(entries); err != nil {
-			logger.Error("failed to import records", slog.String("error", err.Error()))
+		logger.Info("importing complete", slog.Int("numberOfEntries", imported))
+		os.Exit(0)
+	}
+	// Settings can be managed without starting the application.
+	if doSettings || len(sets) > 0 {
+		if err = runSettings(ctx, db, sets, doSettings, os.Stdout); err != nil {
+			logger.Error("failed to update settings", slog.String("error", err.Error()))
 			os.Exit(1)
 		}
-		logger.Info("importing complete", slog.Int("numberOfEntries", len(result)))
 		os.Exit(0)
 	}
 	logger.Debug("database initialized", slog.String("database", dbFile))
-	// Run the application with given database
-	mh := myhours.New(db, myhours.UseLogger(logger))
+	// Run the application with given database. Reads are cached until the
+	// application writes, changes made meanwhile by other processes show up
+	// only after that.
+	mh := myhours.New(myhours.CachingDatabase(db), myhours.UseLogger(logger), myhours.UseKeyBindings(cfg.Keys), myhours.UseTheme(theme), myhours.UseReportClock(reportClock))
 	if _, err = tea.NewProgram(mh).Run(); err != nil {
 		logger.Error("run error", slog.String("error", err.Error()))
 		os.Exit(1)
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/msepp/myhours"
+)
+
+// settingsDatabase is the part of the database needed for managing settings.
+type settingsDatabase interface {
+	myhours.CategoryStore
+	myhours.SettingsStore
+}
+
+// runSettings stores the given settings, in key=value form, and lists all
+// settings if requested. All values are validated before any are stored.
+func runSettings(ctx context.Context, db settingsDatabase, sets []string, list bool, w io.Writer) error {
+	categories, err := db.Categories(ctx)
+	if err != nil {
+		return fmt.Errorf("db.Categories: %w", err)
+	}
+	current, err := db.Settings(ctx)
+	if err != nil {
+		return fmt.Errorf("db.Settings: %w", err)
+	}
+	settings := *current
+	stored := make(map[myhours.Setting]string)
+	var keys []myhours.Setting
+	for _, kv := range sets {
+		key, value, _ := strings.Cut(kv, "=")
+		var s string
+		if settings, s, err = settings.Set(myhours.Setting(key), value, categories); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		if _, ok := stored[myhours.Setting(key)]; !ok {
+			keys = append(keys, myhours.Setting(key))
+		}
+		stored[myhours.Setting(key)] = s
+	}
+	for _, key := range keys {
+		if err = db.UpdateSetting(ctx, key, stored[key]); err != nil {
+			return fmt.Errorf("db.UpdateSetting: %w", err)
+		}
+	}
+	if !list {
+		return nil
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "SETTING\tTYPE\tVALUE\tDESCRIPTION")
+	for _, spec := range myhours.SettingSpecs() {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", spec.Key, spec.Type, spec.Format(settings), spec.Description)
+	}
+	for _, key := range slices.Sorted(maps.Keys(settings.Unknown)) {
+		_, _ = fmt.Fprintf(tw, "%s\tunknown\t%s\tNot supported by this version.\n", key, settings.Unknown[key])
+	}
+	if err = tw.Flush(); err != nil {
+		return fmt.Errorf("tw.Flush: %w", err)
+	}
+	return nil
+}
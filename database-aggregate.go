@@ -0,0 +1,133 @@
+package myhours
+
+import (
+	"cmp"
+	"slices"
+	"sort"
+	"time"
+)
+
+// Period is the length of the calendar periods records are grouped by when
+// aggregating. Periods follow the local time zone.
+type Period int
+
+const (
+	// PeriodAll groups all records of the range together.
+	PeriodAll Period = iota
+	// PeriodDay groups records by day.
+	PeriodDay
+	// PeriodWeek groups records by ISO week, starting on Monday.
+	PeriodWeek
+	// PeriodMonth groups records by month.
+	PeriodMonth
+	// PeriodYear groups records by year.
+	PeriodYear
+)
+
+// next returns the start of the period after the one t is in.
+func (p Period) next(t time.Time) time.Time {
+	y, m, d := t.In(time.Local).Date()
+	switch p {
+	case PeriodDay:
+		return time.Date(y, m, d+1, 0, 0, 0, 0, time.Local)
+	case PeriodWeek:
+		wd := (int(t.In(time.Local).Weekday()) + 6) % 7 // days since Monday
+		return time.Date(y, m, d+7-wd, 0, 0, 0, 0, time.Local)
+	case PeriodMonth:
+		return time.Date(y, m+1, 1, 0, 0, 0, 0, time.Local)
+	case PeriodYear:
+		return time.Date(y+1, 1, 1, 0, 0, 0, 0, time.Local)
+	default:
+		return time.Time{}
+	}
+}
+
+// AggregateQuery selects the records to aggregate, and how to group them.
+type AggregateQuery struct {
+	// From and Before select records by start time, like in Records.
+	From, Before time.Time
+	// CategoryID selects records of a category. If zero, records of all
+	// categories are selected.
+	CategoryID int64
+	// Period groups records by the period they start in.
+	Period Period
+	// ByCategory groups records by category as well.
+	ByCategory bool
+}
+
+// Periods returns the boundaries of the periods in the range of the query: the
+// start of each period, followed by Before. Periods are cut to the range, so
+// the first one starts at From and the last one ends at Before.
+func (q AggregateQuery) Periods() []time.Time {
+	bounds := []time.Time{q.From}
+	for start := q.From; start.Before(q.Before); {
+		next := q.Period.next(start)
+		if next.IsZero() || next.After(q.Before) {
+			next = q.Before
+		}
+		bounds = append(bounds, next)
+		start = next
+	}
+	return bounds
+}
+
+// Aggregate is the totals of a group of finished records.
+type Aggregate struct {
+	// Start of the period of the records.
+	Start time.Time
+	// CategoryID of the records, if grouped by category.
+	CategoryID int64
+	// Records is the number of records.
+	Records int
+	// Duration is the time spent on records, breaks excluded.
+	Duration time.Duration
+	// Breaks is the time spent on breaks.
+	Breaks time.Duration
+	// Pomodoros is the number of pomodoros completed.
+	Pomodoros int
+}
+
+// AggregateRecords aggregates records as the query says, following the
+// contract of RecordStore.Aggregate. For backends that don't aggregate on
+// their own.
+func AggregateRecords(records []Record, q AggregateQuery) []Aggregate {
+	type groupKey struct {
+		period     int
+		categoryID int64
+	}
+	var (
+		bounds = q.Periods()
+		groups = make(map[groupKey]*Aggregate)
+	)
+	for _, record := range records {
+		if !record.Finished() || (q.CategoryID != 0 && record.CategoryID != q.CategoryID) {
+			continue
+		}
+		// the period is the last one starting at or before the record.
+		period := sort.Search(len(bounds), func(i int) bool { return bounds[i].After(record.Start) }) - 1
+		if period < 0 || period >= len(bounds)-1 {
+			continue
+		}
+		key := groupKey{period: period}
+		if q.ByCategory {
+			key.categoryID = record.CategoryID
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &Aggregate{Start: bounds[period], CategoryID: key.categoryID}
+			groups[key] = group
+		}
+		group.Records++
+		group.Duration += record.Duration()
+		group.Breaks += record.BreakDuration(record.End)
+		group.Pomodoros += record.Pomodoros
+	}
+	res := make([]Aggregate, 0, len(groups))
+	for _, group := range groups {
+		res = append(res, *group)
+	}
+	slices.SortFunc(res, func(a, b Aggregate) int {
+		return cmp.Or(a.Start.Compare(b.Start), cmp.Compare(a.CategoryID, b.CategoryID))
+	})
+	return res
+}
@@ -0,0 +1,303 @@
+package myhours
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+)
+
+// cachedQueries is the number of results of each kind of query kept in the
+// cache.
+const cachedQueries = 64
+
+// CachingDatabase wraps every store of db with a cache, see CachingRecords,
+// CachingCategories and CachingSettings. Writes made to db by other means than
+// the returned database are not noticed, so use only when nothing else
+// modifies the database.
+func CachingDatabase(db Database) Database {
+	return NewDatabase(CachingRecords(db), CachingCategories(db), CachingSettings(db))
+}
+
+// CachingRecords wraps records, caching the records and aggregates read from
+// it. Results of up to cachedQueries most recently used queries of records and
+// of aggregates are kept. Records iterated with RecordsSeq and
+// RecordsInCategorySeq are not cached, those are meant for reading more than
+// fits in memory. Writes made through the returned store invalidate the cache.
+func CachingRecords(records RecordStore) RecordStore {
+	return &cachingRecords{
+		RecordStore: records,
+		records:     newLRU[recordsKey, []Record](cachedQueries),
+		aggregates:  newLRU[aggregateKey, []Aggregate](cachedQueries),
+	}
+}
+
+// CachingCategories wraps categories, caching them once read. Categories
+// can't be changed through the stores, so they stay cached.
+func CachingCategories(categories CategoryStore) CategoryStore {
+	return &cachingCategories{CategoryStore: categories}
+}
+
+// CachingSettings wraps settings, caching them until changed through the
+// returned store.
+func CachingSettings(settings SettingsStore) SettingsStore {
+	return &cachingSettings{SettingsStore: settings}
+}
+
+// cachingRecords wraps a record store, caching the results of reads until a
+// write invalidates them.
+type cachingRecords struct {
+	RecordStore
+	mu         sync.Mutex
+	records    *lru[recordsKey, []Record]
+	aggregates *lru[aggregateKey, []Aggregate]
+	// generation is incremented on every write, so that reads that were
+	// started before the write don't store stale results.
+	generation int
+}
+
+// cachingCategories wraps a category store, caching the categories.
+type cachingCategories struct {
+	CategoryStore
+	mu         sync.Mutex
+	categories []Category
+}
+
+// cachingSettings wraps a settings store, caching the settings until a write
+// invalidates them.
+type cachingSettings struct {
+	SettingsStore
+	mu       sync.Mutex
+	settings *Settings
+	// generation is incremented on every write, like in cachingRecords.
+	generation int
+}
+
+// recordsKey identifies the results of Records and RecordsInCategory.
+type recordsKey struct {
+	from, before int64
+	inCategory   bool
+	categoryID   int64
+}
+
+// aggregateKey identifies the results of Aggregate.
+type aggregateKey struct {
+	from, before int64
+	categoryID   int64
+	period       Period
+	byCategory   bool
+}
+
+// lru keeps the values of up to size most recently used keys. Not safe for
+// concurrent use.
+type lru[K comparable, V any] struct {
+	size    int
+	entries map[K]V
+	// recent keys of entries, least recently used first.
+	recent []K
+}
+
+func newLRU[K comparable, V any](size int) *lru[K, V] {
+	return &lru[K, V]{size: size, entries: make(map[K]V, size)}
+}
+
+// get returns the value of key, if any.
+func (c *lru[K, V]) get(key K) (V, bool) {
+	value, ok := c.entries[key]
+	if ok {
+		c.touch(key)
+	}
+	return value, ok
+}
+
+// put sets the value of key, evicting the least recently used key if the
+// cache is full.
+func (c *lru[K, V]) put(key K, value V) {
+	c.entries[key] = value
+	c.touch(key)
+	if len(c.recent) > c.size {
+		delete(c.entries, c.recent[0])
+		c.recent = slices.Delete(c.recent, 0, 1)
+	}
+}
+
+// touch marks key as the most recently used.
+func (c *lru[K, V]) touch(key K) {
+	c.recent = slices.DeleteFunc(c.recent, func(k K) bool { return k == key })
+	c.recent = append(c.recent, key)
+}
+
+// clear drops all values.
+func (c *lru[K, V]) clear() {
+	clear(c.entries)
+	c.recent = c.recent[:0]
+}
+
+// cachedRecords returns the records cached by key, or loads them with load
+// and caches the result.
+func (db *cachingRecords) cachedRecords(key recordsKey, load func() ([]Record, error)) ([]Record, error) {
+	db.mu.Lock()
+	records, ok := db.records.get(key)
+	generation := db.generation
+	db.mu.Unlock()
+	if ok {
+		return cloneRecords(records), nil
+	}
+	records, err := load()
+	if err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	if db.generation == generation {
+		db.records.put(key, cloneRecords(records))
+	}
+	db.mu.Unlock()
+	return records, nil
+}
+
+// invalidate drops cached records and aggregates.
+func (db *cachingRecords) invalidate() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.generation++
+	db.records.clear()
+	db.aggregates.clear()
+}
+
+// cloneRecords returns a copy of records, including their breaks.
+func cloneRecords(records []Record) []Record {
+	res := slices.Clone(records)
+	for i := range res {
+		res[i].Breaks = slices.Clone(res[i].Breaks)
+	}
+	return res
+}
+
+func (db *cachingRecords) Records(ctx context.Context, from, before time.Time) ([]Record, error) {
+	key := recordsKey{from: from.UnixNano(), before: before.UnixNano()}
+	return db.cachedRecords(key, func() ([]Record, error) { return db.RecordStore.Records(ctx, from, before) })
+}
+
+func (db *cachingRecords) RecordsInCategory(ctx context.Context, from, before time.Time, categoryID int64) ([]Record, error) {
+	key := recordsKey{from: from.UnixNano(), before: before.UnixNano(), inCategory: true, categoryID: categoryID}
+	return db.cachedRecords(key, func() ([]Record, error) {
+		return db.RecordStore.RecordsInCategory(ctx, from, before, categoryID)
+	})
+}
+
+func (db *cachingRecords) Aggregate(ctx context.Context, q AggregateQuery) ([]Aggregate, error) {
+	key := aggregateKey{
+		from:       q.From.UnixNano(),
+		before:     q.Before.UnixNano(),
+		categoryID: q.CategoryID,
+		period:     q.Period,
+		byCategory: q.ByCategory,
+	}
+	db.mu.Lock()
+	aggregates, ok := db.aggregates.get(key)
+	generation := db.generation
+	db.mu.Unlock()
+	if ok {
+		return slices.Clone(aggregates), nil
+	}
+	aggregates, err := db.RecordStore.Aggregate(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	if db.generation == generation {
+		db.aggregates.put(key, slices.Clone(aggregates))
+	}
+	db.mu.Unlock()
+	return aggregates, nil
+}
+
+func (db *cachingRecords) ImportRecords(ctx context.Context, records []Record) ([]int64, error) {
+	defer db.invalidate()
+	return db.RecordStore.ImportRecords(ctx, records)
+}
+
+func (db *cachingRecords) StartRecord(ctx context.Context, start time.Time, categoryID int64, notes string) (int64, error) {
+	defer db.invalidate()
+	return db.RecordStore.StartRecord(ctx, start, categoryID, notes)
+}
+
+func (db *cachingRecords) SwitchRecord(ctx context.Context, end, start time.Time, categoryID int64, notes string) (int64, error) {
+	defer db.invalidate()
+	return db.RecordStore.SwitchRecord(ctx, end, start, categoryID, notes)
+}
+
+func (db *cachingRecords) PauseRecord(ctx context.Context, recordID int64, at time.Time) error {
+	defer db.invalidate()
+	return db.RecordStore.PauseRecord(ctx, recordID, at)
+}
+
+func (db *cachingRecords) ResumeRecord(ctx context.Context, recordID int64, at time.Time) error {
+	defer db.invalidate()
+	return db.RecordStore.ResumeRecord(ctx, recordID, at)
+}
+
+func (db *cachingRecords) CompletePomodoro(ctx context.Context, recordID int64, at time.Time) error {
+	defer db.invalidate()
+	return db.RecordStore.CompletePomodoro(ctx, recordID, at)
+}
+
+func (db *cachingRecords) UpdateRecord(ctx context.Context, recordID int64, categoryID int64, start, end time.Time, notes string) error {
+	defer db.invalidate()
+	return db.RecordStore.UpdateRecord(ctx, recordID, categoryID, start, end, notes)
+}
+
+func (db *cachingCategories) Categories(ctx context.Context) ([]Category, error) {
+	db.mu.Lock()
+	categories := db.categories
+	db.mu.Unlock()
+	if categories != nil {
+		return slices.Clone(categories), nil
+	}
+	categories, err := db.CategoryStore.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	db.categories = slices.Clone(categories)
+	db.mu.Unlock()
+	return categories, nil
+}
+
+func (db *cachingSettings) UpdateSetting(ctx context.Context, key Setting, value string) error {
+	defer func() {
+		db.mu.Lock()
+		db.generation++
+		db.settings = nil
+		db.mu.Unlock()
+	}()
+	return db.SettingsStore.UpdateSetting(ctx, key, value)
+}
+
+func (db *cachingSettings) Settings(ctx context.Context) (*Settings, error) {
+	db.mu.Lock()
+	settings, generation := db.settings, db.generation
+	db.mu.Unlock()
+	if settings != nil {
+		return cloneSettings(settings), nil
+	}
+	settings, err := db.SettingsStore.Settings(ctx)
+	if err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	if db.generation == generation {
+		db.settings = cloneSettings(settings)
+	}
+	db.mu.Unlock()
+	return settings, nil
+}
+
+// cloneSettings returns a copy of s that shares no data with it.
+func cloneSettings(s *Settings) *Settings {
+	c := *s
+	c.PinnedTasks = slices.Clone(s.PinnedTasks)
+	c.Unknown = maps.Clone(s.Unknown)
+	return &c
+}
@@ -0,0 +1,48 @@
+package myhours
+
+import (
+	"context"
+	"fmt"
+	"iter"
+)
+
+// ImportRecordsSeq imports the records of seq into db in batches of batchSize
+// records. Each batch is imported with ImportRecords, so each batch is all or
+// nothing on its own. After each batch, progress is called with the number of
+// records imported so far, if not nil.
+//
+// Stops at the first error from seq or db. Batches imported before that stay
+// in the database, the number of records in them is returned with the error.
+func ImportRecordsSeq(ctx context.Context, db RecordStore, records iter.Seq2[Record, error], batchSize int, progress func(imported int)) (int, error) {
+	var (
+		batch    = make([]Record, 0, max(batchSize, 1))
+		imported int
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := db.ImportRecords(ctx, batch); err != nil {
+			return fmt.Errorf("db.ImportRecords: %w", err)
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		if progress != nil {
+			progress(imported)
+		}
+		return nil
+	}
+	for record, err := range records {
+		if err != nil {
+			return imported, fmt.Errorf("read records: %w", err)
+		}
+		batch = append(batch, record)
+		if len(batch) < cap(batch) {
+			continue
+		}
+		if err = flush(); err != nil {
+			return imported, err
+		}
+	}
+	return imported, flush()
+}
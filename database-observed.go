@@ -0,0 +1,201 @@
+package myhours
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"log/slog"
+	"time"
+)
+
+// ObserveFunc is called after each database operation with the name of the
+// method, the time it took and the error it returned, if any.
+type ObserveFunc func(ctx context.Context, method string, took time.Duration, err error)
+
+// LogOperations returns an ObserveFunc logging every operation with l.
+// Successful and cancelled operations are logged at debug level, failed ones
+// as warnings.
+func LogOperations(l *slog.Logger) ObserveFunc {
+	return func(ctx context.Context, method string, took time.Duration, err error) {
+		attrs := []slog.Attr{slog.String("method", method), slog.Duration("took", took)}
+		switch {
+		case err == nil:
+			l.LogAttrs(ctx, slog.LevelDebug, "database operation", attrs...)
+		case errors.Is(err, context.Canceled):
+			l.LogAttrs(ctx, slog.LevelDebug, "database operation cancelled", attrs...)
+		default:
+			l.LogAttrs(ctx, slog.LevelWarn, "database operation failed", append(attrs, slog.String("error", err.Error()))...)
+		}
+	}
+}
+
+// TimeOperations returns an ObserveFunc passing the duration of every
+// operation to record, for example to collect metrics. Failed operations are
+// included.
+func TimeOperations(record func(method string, took time.Duration)) ObserveFunc {
+	return func(_ context.Context, method string, took time.Duration, _ error) {
+		record(method, took)
+	}
+}
+
+// ObserveDatabase wraps every store of db with observe.
+func ObserveDatabase(db Database, observe ObserveFunc) Database {
+	return NewDatabase(ObserveRecords(db, observe), ObserveCategories(db, observe), ObserveSettings(db, observe))
+}
+
+// LoggingDatabase wraps db, logging every operation with l, see LogOperations.
+func LoggingDatabase(db Database, l *slog.Logger) Database {
+	return ObserveDatabase(db, LogOperations(l))
+}
+
+// TimingDatabase wraps db, passing the duration of every operation to record,
+// see TimeOperations.
+func TimingDatabase(db Database, record func(method string, took time.Duration)) Database {
+	return ObserveDatabase(db, TimeOperations(record))
+}
+
+// ObserveRecords wraps records, calling observe after every operation.
+func ObserveRecords(records RecordStore, observe ObserveFunc) RecordStore {
+	return observedRecords{store: records, observe: observe}
+}
+
+// ObserveCategories wraps categories, calling observe after every operation.
+func ObserveCategories(categories CategoryStore, observe ObserveFunc) CategoryStore {
+	return observedCategories{store: categories, observe: observe}
+}
+
+// ObserveSettings wraps settings, calling observe after every operation.
+func ObserveSettings(settings SettingsStore, observe ObserveFunc) SettingsStore {
+	return observedSettings{store: settings, observe: observe}
+}
+
+// observedRecords wraps a record store, calling observe after every operation.
+type observedRecords struct {
+	store   RecordStore
+	observe ObserveFunc
+}
+
+// observedCategories wraps a category store, calling observe after every
+// operation.
+type observedCategories struct {
+	store   CategoryStore
+	observe ObserveFunc
+}
+
+// observedSettings wraps a settings store, calling observe after every
+// operation.
+type observedSettings struct {
+	store   SettingsStore
+	observe ObserveFunc
+}
+
+// observe runs f, and passes its result to fn.
+func observe[T any](ctx context.Context, fn ObserveFunc, method string, f func() (T, error)) (T, error) {
+	start := time.Now()
+	v, err := f()
+	fn(ctx, method, time.Since(start), err)
+	return v, err
+}
+
+// observeSeq iterates seq, and passes the time the iteration took and the
+// error that stopped it, if any, to fn. The time includes the time spent by
+// the caller on each item.
+func observeSeq[T any](ctx context.Context, fn ObserveFunc, method string, seq iter.Seq2[T, error]) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		start := time.Now()
+		var err error
+		for v, e := range seq {
+			err = e
+			if !yield(v, e) {
+				break
+			}
+		}
+		fn(ctx, method, time.Since(start), err)
+	}
+}
+
+// observeErr is observe for operations that only return an error.
+func observeErr(ctx context.Context, fn ObserveFunc, method string, f func() error) error {
+	_, err := observe(ctx, fn, method, func() (struct{}, error) { return struct{}{}, f() })
+	return err
+}
+
+func (s observedRecords) ActiveRecord(ctx context.Context) (*Record, error) {
+	return observe(ctx, s.observe, "ActiveRecord", func() (*Record, error) { return s.store.ActiveRecord(ctx) })
+}
+
+func (s observedRecords) Record(ctx context.Context, recordID int64) (*Record, error) {
+	return observe(ctx, s.observe, "Record", func() (*Record, error) { return s.store.Record(ctx, recordID) })
+}
+
+func (s observedRecords) LastRecord(ctx context.Context) (*Record, error) {
+	return observe(ctx, s.observe, "LastRecord", func() (*Record, error) { return s.store.LastRecord(ctx) })
+}
+
+func (s observedRecords) Records(ctx context.Context, from, before time.Time) ([]Record, error) {
+	return observe(ctx, s.observe, "Records", func() ([]Record, error) { return s.store.Records(ctx, from, before) })
+}
+
+func (s observedRecords) RecordsInCategory(ctx context.Context, from, before time.Time, categoryID int64) ([]Record, error) {
+	return observe(ctx, s.observe, "RecordsInCategory", func() ([]Record, error) {
+		return s.store.RecordsInCategory(ctx, from, before, categoryID)
+	})
+}
+
+func (s observedRecords) RecordsSeq(ctx context.Context, from, before time.Time) iter.Seq2[Record, error] {
+	return observeSeq(ctx, s.observe, "RecordsSeq", s.store.RecordsSeq(ctx, from, before))
+}
+
+func (s observedRecords) RecordsInCategorySeq(ctx context.Context, from, before time.Time, categoryID int64) iter.Seq2[Record, error] {
+	return observeSeq(ctx, s.observe, "RecordsInCategorySeq", s.store.RecordsInCategorySeq(ctx, from, before, categoryID))
+}
+
+func (s observedRecords) Aggregate(ctx context.Context, q AggregateQuery) ([]Aggregate, error) {
+	return observe(ctx, s.observe, "Aggregate", func() ([]Aggregate, error) { return s.store.Aggregate(ctx, q) })
+}
+
+func (s observedRecords) RecentTasks(ctx context.Context, limit int) ([]Task, error) {
+	return observe(ctx, s.observe, "RecentTasks", func() ([]Task, error) { return s.store.RecentTasks(ctx, limit) })
+}
+
+func (s observedRecords) ImportRecords(ctx context.Context, records []Record) ([]int64, error) {
+	return observe(ctx, s.observe, "ImportRecords", func() ([]int64, error) { return s.store.ImportRecords(ctx, records) })
+}
+
+func (s observedRecords) StartRecord(ctx context.Context, start time.Time, categoryID int64, notes string) (int64, error) {
+	return observe(ctx, s.observe, "StartRecord", func() (int64, error) { return s.store.StartRecord(ctx, start, categoryID, notes) })
+}
+
+func (s observedRecords) SwitchRecord(ctx context.Context, end, start time.Time, categoryID int64, notes string) (int64, error) {
+	return observe(ctx, s.observe, "SwitchRecord", func() (int64, error) { return s.store.SwitchRecord(ctx, end, start, categoryID, notes) })
+}
+
+func (s observedRecords) PauseRecord(ctx context.Context, recordID int64, at time.Time) error {
+	return observeErr(ctx, s.observe, "PauseRecord", func() error { return s.store.PauseRecord(ctx, recordID, at) })
+}
+
+func (s observedRecords) ResumeRecord(ctx context.Context, recordID int64, at time.Time) error {
+	return observeErr(ctx, s.observe, "ResumeRecord", func() error { return s.store.ResumeRecord(ctx, recordID, at) })
+}
+
+func (s observedRecords) CompletePomodoro(ctx context.Context, recordID int64, at time.Time) error {
+	return observeErr(ctx, s.observe, "CompletePomodoro", func() error { return s.store.CompletePomodoro(ctx, recordID, at) })
+}
+
+func (s observedRecords) UpdateRecord(ctx context.Context, recordID int64, categoryID int64, start, end time.Time, notes string) error {
+	return observeErr(ctx, s.observe, "UpdateRecord", func() error {
+		return s.store.UpdateRecord(ctx, recordID, categoryID, start, end, notes)
+	})
+}
+
+func (s observedCategories) Categories(ctx context.Context) ([]Category, error) {
+	return observe(ctx, s.observe, "Categories", func() ([]Category, error) { return s.store.Categories(ctx) })
+}
+
+func (s observedSettings) UpdateSetting(ctx context.Context, key Setting, value string) error {
+	return observeErr(ctx, s.observe, "UpdateSetting", func() error { return s.store.UpdateSetting(ctx, key, value) })
+}
+
+func (s observedSettings) Settings(ctx context.Context) (*Settings, error) {
+	return observe(ctx, s.observe, "Settings", func() (*Settings, error) { return s.store.Settings(ctx) })
+}
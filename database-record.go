@@ -18,6 +18,41 @@ type Record struct {
 	CategoryID int64
 	// Notes for this particular record.
 	Notes string
+	// Breaks taken during the record, in chronological order.
+	Breaks []Break
+	// Pomodoros is the number of pomodoros completed during the record.
+	Pomodoros int
+}
+
+// Task is a combination of category and notes that records often repeat, for
+// example a daily standup.
+type Task struct {
+	// CategoryID of records for the task.
+	CategoryID int64 `json:"category"`
+	// Notes of records for the task.
+	Notes string `json:"notes"`
+}
+
+// Break is a pause within a record. Time spent on breaks is not counted in
+// the record duration.
+type Break struct {
+	// Start datetime, when the break started.
+	Start time.Time
+	// End datetime, when the break ended. If zero, break is still on.
+	End time.Time
+}
+
+// Duration of the break until given time, which is used as the end time for
+// breaks that are still on.
+func (b Break) Duration(until time.Time) time.Duration {
+	end := b.End
+	if end.IsZero() || end.After(until) {
+		end = until
+	}
+	if !end.After(b.Start) {
+		return 0
+	}
+	return end.Sub(b.Start)
 }
 
 // Finished returns if the record has been finished.
@@ -30,12 +65,28 @@ func (r Record) Active() bool {
 	return !r.Start.IsZero() && !r.Finished()
 }
 
-// Duration of the record. If Start or End is zero, return value is zero.
+// Paused returns if the record is active, and a break is on.
+func (r Record) Paused() bool {
+	return r.Active() && len(r.Breaks) > 0 && r.Breaks[len(r.Breaks)-1].End.IsZero()
+}
+
+// Duration of the record, excluding breaks. If Start or End is zero, return
+// value is zero.
 func (r Record) Duration() time.Duration {
 	if r.Start.IsZero() || r.End.IsZero() {
 		return 0
 	}
-	return r.End.Sub(r.Start)
+	return r.End.Sub(r.Start) - r.BreakDuration(r.End)
+}
+
+// BreakDuration returns the total duration of breaks until given time. Breaks
+// that are still on are counted until the given time.
+func (r Record) BreakDuration(until time.Time) time.Duration {
+	var total time.Duration
+	for _, b := range r.Breaks {
+		total += b.Duration(until)
+	}
+	return total
 }
 
 // Validate Record for any inconsistencies. Returns error with validation failure
@@ -1,49 +1,124 @@
 package myhours
 
 import (
+	"context"
+	"errors"
+	"iter"
 	"time"
 )
 
-// Setting identifies a value in application configuration settings.
-type Setting string
+// ErrTransient marks errors of database operations that may succeed when
+// retried, such as the database being locked by another process or failing to
+// write to disk. Other errors are permanent, retrying doesn't help.
+var ErrTransient = errors.New("temporary database failure")
 
-func (s Setting) String() string { return string(s) }
+// Database defines the database access requirements for stopwatch. It is
+// composed of the stores for each kind of data, so that backends and test
+// doubles can be built from separate parts, and code needing only some of the
+// data can ask for only that.
+//
+// All methods take a context, and should return the context error if it is
+// cancelled before the operation completes. Errors of writes that may succeed
+// when retried should wrap ErrTransient.
+type Database interface {
+	RecordStore
+	CategoryStore
+	SettingsStore
+}
 
-const (
-	// SettingDefaultCategory is the setting key for default category.
-	SettingDefaultCategory Setting = "default_category"
-)
+// NewDatabase composes a Database from separate stores, for example to combine
+// a test double of one store with a real backend for the others.
+func NewDatabase(records RecordStore, categories CategoryStore, settings SettingsStore) Database {
+	return struct {
+		RecordStore
+		CategoryStore
+		SettingsStore
+	}{records, categories, settings}
+}
 
-// Database defines the database access requirements for stopwatch.
-type Database interface {
+// RecordStore stores the records of time spent.
+//
+// Records returned from the store have their breaks included.
+type RecordStore interface {
 	// ActiveRecord returns currently active record.
 	//
 	// If none is active, both return values are nil.
-	ActiveRecord() (*Record, error)
+	ActiveRecord(ctx context.Context) (*Record, error)
 	// Record returns a single Record matching given ID.
-	Record(recordID int64) (*Record, error)
+	Record(ctx context.Context, recordID int64) (*Record, error)
+	// LastRecord returns the finished record with the latest end time. Used to
+	// prevent new records from overlapping earlier ones.
+	//
+	// If there are no finished records, both return values are nil.
+	LastRecord(ctx context.Context) (*Record, error)
 	// Records returns all records that fit into the given timespan.
 	// Records where starting time is equal or greater to from, and less than before,
-	// are returned.
-	Records(from, before time.Time) ([]Record, error)
+	// are returned, the active record included. Records are ordered by start time.
+	Records(ctx context.Context, from, before time.Time) ([]Record, error)
 	// RecordsInCategory behaves exactly like Records, but filters also by given
 	// categoryID.
-	RecordsInCategory(from, before time.Time, categoryID int64) ([]Record, error)
+	RecordsInCategory(ctx context.Context, from, before time.Time, categoryID int64) ([]Record, error)
+	// RecordsSeq iterates the records Records returns, without holding all of
+	// them in memory at once. Iteration stops at the first error, which is
+	// yielded with a zero Record. Records may be read in pages, so changes made
+	// during iteration may or may not be seen.
+	RecordsSeq(ctx context.Context, from, before time.Time) iter.Seq2[Record, error]
+	// RecordsInCategorySeq iterates the records RecordsInCategory returns, like
+	// RecordsSeq.
+	RecordsInCategorySeq(ctx context.Context, from, before time.Time, categoryID int64) iter.Seq2[Record, error]
+	// Aggregate returns the totals of finished records selected by the query,
+	// grouped as the query says. Groups without records are left out.
+	// Aggregates are ordered by period start, then by category ID. Durations
+	// may be rounded to milliseconds.
+	Aggregate(ctx context.Context, q AggregateQuery) ([]Aggregate, error)
+	// RecentTasks returns up to limit distinct tasks from records, most recently
+	// started first.
+	RecentTasks(ctx context.Context, limit int) ([]Task, error)
 	// ImportRecords with given details. Expects that all records are finished.
+	// Either all records are imported, or none are.
 	//
 	// On success returns the imported record ID.
-	ImportRecords(records []Record) ([]int64, error)
+	ImportRecords(ctx context.Context, records []Record) ([]int64, error)
 	// StartRecord inserts a new active record into the database. If an already active
 	// record exist, error is returned instead.
 	//
 	// On success returns the new record IDs
-	StartRecord(start time.Time, categoryID int64, notes string) (int64, error)
-	// UpdateRecord details for record identified by record ID.
-	UpdateRecord(recordID int64, categoryID int64, from, end time.Time, notes string) error
+	StartRecord(ctx context.Context, start time.Time, categoryID int64, notes string) (int64, error)
+	// SwitchRecord ends the active record at end, and starts a new active record
+	// at start, which can't be before end. Any break still on for the ended
+	// record is ended as well. If no record is active, only the new record is
+	// started. The change is atomic, either both happen or neither does.
+	//
+	// On success returns the ID of the new record.
+	SwitchRecord(ctx context.Context, end, start time.Time, categoryID int64, notes string) (int64, error)
+	// PauseRecord starts a break for the active record identified by record ID.
+	// Returns an error if the record is not active, or already has a break on.
+	PauseRecord(ctx context.Context, recordID int64, at time.Time) error
+	// ResumeRecord ends the break that is on for the record identified by
+	// record ID. Returns an error if the record has no break on.
+	ResumeRecord(ctx context.Context, recordID int64, at time.Time) error
+	// CompletePomodoro registers a completed pomodoro for the record identified
+	// by record ID.
+	CompletePomodoro(ctx context.Context, recordID int64, at time.Time) error
+	// UpdateRecord details for record identified by record ID. If end is set,
+	// any break still on for the record is ended at the same time. Returns an
+	// error if there is no such record.
+	UpdateRecord(ctx context.Context, recordID int64, categoryID int64, from, end time.Time, notes string) error
+}
+
+// CategoryStore provides the categories of records.
+type CategoryStore interface {
 	// Categories returns all available categories.
-	Categories() ([]Category, error)
-	// UpdateSetting updates a configuration setting value identified by key.
-	UpdateSetting(key Setting, value string) error
-	// Settings returns application settings
-	Settings() (*Settings, error)
+	Categories(ctx context.Context) ([]Category, error)
+}
+
+// SettingsStore stores the application settings.
+type SettingsStore interface {
+	// UpdateSetting stores a configuration setting value identified by key,
+	// creating the setting if it doesn't exist yet. Implementations should
+	// check the value with ValidateSetting.
+	UpdateSetting(ctx context.Context, key Setting, value string) error
+	// Settings returns application settings. Implementations should build
+	// the settings from stored values with ParseSettings.
+	Settings(ctx context.Context) (*Settings, error)
 }
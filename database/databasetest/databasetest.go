@@ -0,0 +1,661 @@
+// Package databasetest contains a conformance test suite for myhours.Database
+// implementations, so that all backends behave the same.
+//
+// Run the suite from the tests of a backend:
+//
+//	func TestSQLite(t *testing.T) {
+//		databasetest.Run(t, func(t *testing.T) myhours.Database {
+//			return newTestDatabase(t)
+//		})
+//	}
+package databasetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/msepp/myhours"
+)
+
+// Factory returns a new, empty database for a test. The database must have at
+// least two categories, and no settings stored other than the defaults. Use
+// t.Cleanup to release resources.
+type Factory func(t *testing.T) myhours.Database
+
+// day is the day records are created on in tests.
+var day = time.Date(2026, 1, 5, 0, 0, 0, 0, time.Local)
+
+// Run runs the conformance tests against databases created by factory, each
+// test with a database of its own.
+func Run(t *testing.T, factory Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, db myhours.Database)
+	}{
+		{name: "StartRecord", test: testStartRecord},
+		{name: "SwitchRecord", test: testSwitchRecord},
+		{name: "Record", test: testRecord},
+		{name: "LastRecord", test: testLastRecord},
+		{name: "Records", test: testRecords},
+		{name: "RecordsInCategory", test: testRecordsInCategory},
+		{name: "RecordsSeq", test: testRecordsSeq},
+		{name: "RecentTasks", test: testRecentTasks},
+		{name: "Aggregate", test: testAggregate},
+		{name: "ImportRecords", test: testImportRecords},
+		{name: "ImportRecords rollback", test: testImportRollback},
+		{name: "UpdateRecord", test: testUpdateRecord},
+		{name: "PauseRecord", test: testPauseRecord},
+		{name: "CompletePomodoro", test: testCompletePomodoro},
+		{name: "local time", test: testLocalTime},
+		{name: "Categories", test: testCategories},
+		{name: "Settings", test: testSettings},
+		{name: "cancelled context", test: testCancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, factory(t))
+		})
+	}
+}
+
+// categories returns the IDs of the first two categories of db.
+func categories(t *testing.T, db myhours.Database) (int64, int64) {
+	t.Helper()
+	categories, err := db.Categories(context.Background())
+	if err != nil {
+		t.Fatalf("Categories() error = %v", err)
+	}
+	if len(categories) < 2 {
+		t.Fatalf("Categories() = %v, need at least two", categories)
+	}
+	return categories[0].ID, categories[1].ID
+}
+
+// mustImport imports records, failing the test on error.
+func mustImport(t *testing.T, db myhours.Database, records ...myhours.Record) []int64 {
+	t.Helper()
+	ids, err := db.ImportRecords(context.Background(), records)
+	if err != nil {
+		t.Fatalf("ImportRecords() error = %v", err)
+	}
+	return ids
+}
+
+// mustRecord returns the record identified by id, failing the test if there is
+// no such record.
+func mustRecord(t *testing.T, db myhours.Database, id int64) myhours.Record {
+	t.Helper()
+	record, err := db.Record(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Record(%d) error = %v", id, err)
+	}
+	if record == nil {
+		t.Fatalf("Record(%d) = nil, want record", id)
+	}
+	return *record
+}
+
+// notes returns the notes of records, for comparing results by notes.
+func notes(records []myhours.Record) []string {
+	res := make([]string, 0, len(records))
+	for _, record := range records {
+		res = append(res, record.Notes)
+	}
+	return res
+}
+
+func testStartRecord(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, _ := categories(t, db)
+	if active, err := db.ActiveRecord(ctx); active != nil || err != nil {
+		t.Fatalf("ActiveRecord() = %v, %v, want nil, nil for empty database", active, err)
+	}
+	id, err := db.StartRecord(ctx, day.Add(9*time.Hour), cat, "standup")
+	if err != nil {
+		t.Fatalf("StartRecord() error = %v", err)
+	}
+	if _, err = db.StartRecord(ctx, day.Add(10*time.Hour), cat, "other"); err == nil {
+		t.Error("StartRecord() with a record active, want error")
+	}
+	active, err := db.ActiveRecord(ctx)
+	if err != nil || active == nil {
+		t.Fatalf("ActiveRecord() = %v, %v, want record", active, err)
+	}
+	if active.ID != id || active.Notes != "standup" || !active.Start.Equal(day.Add(9*time.Hour)) {
+		t.Errorf("ActiveRecord() = %+v, want the started record %d", active, id)
+	}
+}
+
+func testSwitchRecord(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, other := categories(t, db)
+	// without an active record, only starts a new one.
+	first, err := db.SwitchRecord(ctx, day.Add(9*time.Hour), day.Add(9*time.Hour), cat, "first")
+	if err != nil {
+		t.Fatalf("SwitchRecord() error = %v", err)
+	}
+	if err = db.PauseRecord(ctx, first, day.Add(9*time.Hour+30*time.Minute)); err != nil {
+		t.Fatalf("PauseRecord() error = %v", err)
+	}
+	if _, err = db.SwitchRecord(ctx, day.Add(9*time.Hour), day.Add(9*time.Hour), other, "too early"); err == nil {
+		t.Error("SwitchRecord() at start of the active record, want error")
+	}
+	switchAt := day.Add(10 * time.Hour)
+	if _, err = db.SwitchRecord(ctx, switchAt, switchAt.Add(-time.Minute), other, "overlapping"); err == nil {
+		t.Error("SwitchRecord() starting before the end, want error")
+	}
+	if ended := mustRecord(t, db, first); !ended.End.IsZero() {
+		t.Errorf("record after failed SwitchRecord() = %+v, want still active", ended)
+	}
+	second, err := db.SwitchRecord(ctx, switchAt, switchAt, other, "second")
+	if err != nil {
+		t.Fatalf("SwitchRecord() error = %v", err)
+	}
+	ended := mustRecord(t, db, first)
+	if !ended.End.Equal(switchAt) {
+		t.Errorf("switched record end = %v, want %v", ended.End, switchAt)
+	}
+	if len(ended.Breaks) != 1 || !ended.Breaks[0].End.Equal(switchAt) {
+		t.Errorf("switched record breaks = %v, want break ended at %v", ended.Breaks, switchAt)
+	}
+	active, err := db.ActiveRecord(ctx)
+	if err != nil || active == nil || active.ID != second || active.CategoryID != other {
+		t.Errorf("ActiveRecord() = %+v, %v, want record %d in category %d", active, err, second, other)
+	}
+	// the new record may start later than the active one ends.
+	endAt, startAt := day.Add(11*time.Hour), day.Add(12*time.Hour)
+	third, err := db.SwitchRecord(ctx, endAt, startAt, cat, "third")
+	if err != nil {
+		t.Fatalf("SwitchRecord() error = %v", err)
+	}
+	if ended := mustRecord(t, db, second); !ended.End.Equal(endAt) {
+		t.Errorf("switched record end = %v, want %v", ended.End, endAt)
+	}
+	if started := mustRecord(t, db, third); !started.Start.Equal(startAt) || !started.End.IsZero() {
+		t.Errorf("started record = %+v, want active from %v", started, startAt)
+	}
+}
+
+func testRecord(t *testing.T, db myhours.Database) {
+	cat, _ := categories(t, db)
+	ids := mustImport(t, db, myhours.Record{Start: day.Add(9 * time.Hour), End: day.Add(10 * time.Hour), CategoryID: cat, Notes: "notes"})
+	if got := mustRecord(t, db, ids[0]); got.Notes != "notes" || got.CategoryID != cat {
+		t.Errorf("Record() = %+v, want imported record", got)
+	}
+	if record, err := db.Record(context.Background(), ids[0]+100); record != nil || err != nil {
+		t.Errorf("Record() = %v, %v, want nil, nil for unknown ID", record, err)
+	}
+}
+
+func testLastRecord(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, _ := categories(t, db)
+	if record, err := db.LastRecord(ctx); record != nil || err != nil {
+		t.Fatalf("LastRecord() = %v, %v, want nil, nil for empty database", record, err)
+	}
+	mustImport(t, db,
+		myhours.Record{Start: day.Add(9 * time.Hour), End: day.Add(17 * time.Hour), CategoryID: cat, Notes: "ends last"},
+		myhours.Record{Start: day.Add(10 * time.Hour), End: day.Add(11 * time.Hour), CategoryID: cat, Notes: "starts last"},
+	)
+	if _, err := db.StartRecord(ctx, day.Add(18*time.Hour), cat, "active"); err != nil {
+		t.Fatalf("StartRecord() error = %v", err)
+	}
+	record, err := db.LastRecord(ctx)
+	if err != nil || record == nil || record.Notes != "ends last" {
+		t.Errorf("LastRecord() = %+v, %v, want the record that ended last", record, err)
+	}
+}
+
+// importRange imports records around the day in given categories, and starts
+// an active record at the end of the day.
+func importRange(t *testing.T, db myhours.Database, cat, other int64) {
+	t.Helper()
+	before := day.AddDate(0, 0, 1)
+	mustImport(t, db,
+		myhours.Record{Start: day.Add(-time.Hour), End: day.Add(time.Hour), CategoryID: cat, Notes: "starts before"},
+		myhours.Record{Start: day, End: day.Add(time.Hour), CategoryID: cat, Notes: "starts at from"},
+		myhours.Record{Start: day.Add(2 * time.Hour), End: day.Add(3 * time.Hour), CategoryID: other, Notes: "other category"},
+		myhours.Record{Start: before.Add(-2 * time.Hour), End: before.Add(time.Hour), CategoryID: cat, Notes: "ends after"},
+		myhours.Record{Start: before, End: before.Add(time.Hour), CategoryID: cat, Notes: "starts at before"},
+	)
+	if _, err := db.StartRecord(context.Background(), before.Add(-time.Hour), cat, "active"); err != nil {
+		t.Fatalf("StartRecord() error = %v", err)
+	}
+}
+
+func testRecords(t *testing.T, db myhours.Database) {
+	cat, other := categories(t, db)
+	importRange(t, db, cat, other)
+	records, err := db.Records(context.Background(), day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	want := []string{"starts at from", "other category", "ends after", "active"}
+	if got := notes(records); !slices.Equal(got, want) {
+		t.Errorf("Records() = %v, want %v", got, want)
+	}
+}
+
+func testRecordsInCategory(t *testing.T, db myhours.Database) {
+	cat, other := categories(t, db)
+	importRange(t, db, cat, other)
+	tests := []struct {
+		categoryID int64
+		want       []string
+	}{
+		{categoryID: cat, want: []string{"starts at from", "ends after", "active"}},
+		{categoryID: other, want: []string{"other category"}},
+		{categoryID: other + 100, want: []string{}},
+	}
+	for _, tt := range tests {
+		records, err := db.RecordsInCategory(context.Background(), day, day.AddDate(0, 0, 1), tt.categoryID)
+		if err != nil {
+			t.Fatalf("RecordsInCategory() error = %v", err)
+		}
+		if got := notes(records); !slices.Equal(got, tt.want) {
+			t.Errorf("RecordsInCategory(%d) = %v, want %v", tt.categoryID, got, tt.want)
+		}
+	}
+}
+
+func testRecordsSeq(t *testing.T, db myhours.Database) {
+	cat, other := categories(t, db)
+	importRange(t, db, cat, other)
+	var (
+		ctx    = context.Background()
+		before = day.AddDate(0, 0, 1)
+	)
+	tests := []struct {
+		name  string
+		seq   iter.Seq2[myhours.Record, error]
+		limit int
+		want  []string
+	}{
+		{name: "all", seq: db.RecordsSeq(ctx, day, before), want: []string{"starts at from", "other category", "ends after", "active"}},
+		{name: "stopped", seq: db.RecordsSeq(ctx, day, before), limit: 3, want: []string{"starts at from", "other category", "ends after"}},
+		{name: "in category", seq: db.RecordsInCategorySeq(ctx, day, before, cat), want: []string{"starts at from", "ends after", "active"}},
+		{name: "no records", seq: db.RecordsInCategorySeq(ctx, day, before, other+100), want: []string{}},
+	}
+	for _, tt := range tests {
+		var records []myhours.Record
+		for record, err := range tt.seq {
+			if err != nil {
+				t.Fatalf("%s: error = %v", tt.name, err)
+			}
+			if records = append(records, record); len(records) == tt.limit {
+				break
+			}
+		}
+		if got := notes(records); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: records = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	// records come with their breaks, and in the same order as from Records
+	// when they start at the same time.
+	at := day.Add(5 * time.Hour)
+	mustImport(t, db,
+		myhours.Record{Start: at, End: at.Add(time.Hour), CategoryID: cat, Notes: "with break", Breaks: []myhours.Break{{Start: at.Add(time.Minute), End: at.Add(2 * time.Minute)}}},
+		myhours.Record{Start: at, End: at.Add(time.Hour), CategoryID: other, Notes: "same start"},
+	)
+	want, err := db.Records(ctx, day, before)
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	var got []myhours.Record
+	for record, err := range db.RecordsSeq(ctx, day, before) {
+		if err != nil {
+			t.Fatalf("RecordsSeq() error = %v", err)
+		}
+		got = append(got, record)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RecordsSeq() = %v, want %v", got, want)
+	}
+}
+
+func testRecentTasks(t *testing.T, db myhours.Database) {
+	cat, other := categories(t, db)
+	mustImport(t, db,
+		myhours.Record{Start: day.Add(9 * time.Hour), End: day.Add(10 * time.Hour), CategoryID: cat, Notes: "a"},
+		myhours.Record{Start: day.Add(10 * time.Hour), End: day.Add(11 * time.Hour), CategoryID: other, Notes: "a"},
+		myhours.Record{Start: day.Add(11 * time.Hour), End: day.Add(12 * time.Hour), CategoryID: cat, Notes: "b"},
+		myhours.Record{Start: day.Add(12 * time.Hour), End: day.Add(13 * time.Hour), CategoryID: cat, Notes: "a"},
+	)
+	tasks, err := db.RecentTasks(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("RecentTasks() error = %v", err)
+	}
+	want := []myhours.Task{{CategoryID: cat, Notes: "a"}, {CategoryID: cat, Notes: "b"}}
+	if !slices.Equal(tasks, want) {
+		t.Errorf("RecentTasks() = %v, want %v", tasks, want)
+	}
+}
+
+// aggregates returns aggregates as strings, for comparing results.
+func aggregates(aggregates []myhours.Aggregate) []string {
+	res := []string{}
+	for _, a := range aggregates {
+		res = append(res, fmt.Sprintf("%s category %d: %d records, %v, breaks %v, %d pomodoros",
+			a.Start.Local().Format("2006-01-02 15:04"), a.CategoryID, a.Records, a.Duration, a.Breaks, a.Pomodoros))
+	}
+	return res
+}
+
+func testAggregate(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, other := categories(t, db)
+	next := day.AddDate(0, 0, 1)
+	mustImport(t, db,
+		myhours.Record{Start: day.Add(-time.Hour), End: day, CategoryID: cat, Notes: "before range"},
+		myhours.Record{
+			Start: day.Add(9 * time.Hour), End: day.Add(11 * time.Hour), CategoryID: cat, Pomodoros: 2,
+			Breaks: []myhours.Break{{Start: day.Add(10 * time.Hour), End: day.Add(10*time.Hour + 30*time.Minute)}},
+		},
+		myhours.Record{Start: day.Add(13 * time.Hour), End: day.Add(14 * time.Hour), CategoryID: other},
+		myhours.Record{Start: next.Add(9 * time.Hour), End: next.Add(10 * time.Hour), CategoryID: cat},
+		// local midnight decides the day, not the one in UTC.
+		myhours.Record{Start: next.Add(23*time.Hour + 30*time.Minute), End: next.Add(23*time.Hour + 50*time.Minute), CategoryID: cat},
+		myhours.Record{Start: day.AddDate(0, 0, 7).Add(9 * time.Hour), End: day.AddDate(0, 0, 7).Add(9*time.Hour + 45*time.Minute), CategoryID: cat},
+	)
+	if _, err := db.StartRecord(ctx, day.AddDate(0, 0, 2).Add(9*time.Hour), cat, "active"); err != nil {
+		t.Fatalf("StartRecord() error = %v", err)
+	}
+	tests := []struct {
+		name string
+		q    myhours.AggregateQuery
+		want []string
+	}{
+		{
+			name: "by day",
+			q:    myhours.AggregateQuery{Period: myhours.PeriodDay},
+			want: []string{
+				"2026-01-05 00:00 category 0: 2 records, 2h30m0s, breaks 30m0s, 2 pomodoros",
+				"2026-01-06 00:00 category 0: 2 records, 1h20m0s, breaks 0s, 0 pomodoros",
+				"2026-01-12 00:00 category 0: 1 records, 45m0s, breaks 0s, 0 pomodoros",
+			},
+		},
+		{
+			name: "by week and category",
+			q:    myhours.AggregateQuery{Period: myhours.PeriodWeek, ByCategory: true},
+			want: []string{
+				fmt.Sprintf("2026-01-05 00:00 category %d: 3 records, 2h50m0s, breaks 30m0s, 2 pomodoros", cat),
+				fmt.Sprintf("2026-01-05 00:00 category %d: 1 records, 1h0m0s, breaks 0s, 0 pomodoros", other),
+				fmt.Sprintf("2026-01-12 00:00 category %d: 1 records, 45m0s, breaks 0s, 0 pomodoros", cat),
+			},
+		},
+		{
+			name: "in category",
+			q:    myhours.AggregateQuery{CategoryID: other},
+			want: []string{"2026-01-05 00:00 category 0: 1 records, 1h0m0s, breaks 0s, 0 pomodoros"},
+		},
+		{
+			name: "month cut to range",
+			q:    myhours.AggregateQuery{Period: myhours.PeriodMonth},
+			want: []string{"2026-01-05 00:00 category 0: 5 records, 4h35m0s, breaks 30m0s, 2 pomodoros"},
+		},
+		{
+			name: "no records",
+			q:    myhours.AggregateQuery{Period: myhours.PeriodDay, CategoryID: other + 100},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		tt.q.From, tt.q.Before = day, day.AddDate(0, 0, 14)
+		res, err := db.Aggregate(ctx, tt.q)
+		if err != nil {
+			t.Fatalf("Aggregate(%s) error = %v", tt.name, err)
+		}
+		if got := aggregates(res); !slices.Equal(got, tt.want) {
+			t.Errorf("Aggregate(%s) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testImportRecords(t *testing.T, db myhours.Database) {
+	cat, _ := categories(t, db)
+	start := day.Add(9 * time.Hour)
+	ids := mustImport(t, db, myhours.Record{
+		Start:      start,
+		End:        start.Add(2 * time.Hour),
+		CategoryID: cat,
+		Notes:      "imported",
+		// break still on is ended with the record.
+		Breaks:    []myhours.Break{{Start: start.Add(time.Hour), End: start.Add(90 * time.Minute)}, {Start: start.Add(110 * time.Minute)}},
+		Pomodoros: 2,
+	})
+	if len(ids) != 1 {
+		t.Fatalf("ImportRecords() = %v, want one ID", ids)
+	}
+	got := mustRecord(t, db, ids[0])
+	if !got.Start.Equal(start) || !got.End.Equal(start.Add(2*time.Hour)) || got.Pomodoros != 2 {
+		t.Errorf("imported record = %+v", got)
+	}
+	if len(got.Breaks) != 2 || !got.Breaks[1].End.Equal(got.End) {
+		t.Errorf("imported breaks = %v, want last break ended with the record", got.Breaks)
+	}
+	if d := got.Duration(); d != 80*time.Minute {
+		t.Errorf("imported record duration = %v, want 1h20m", d)
+	}
+}
+
+func testImportRollback(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, _ := categories(t, db)
+	valid := myhours.Record{Start: day.Add(9 * time.Hour), End: day.Add(10 * time.Hour), CategoryID: cat}
+	tests := []struct {
+		name   string
+		record myhours.Record
+	}{
+		{name: "unfinished", record: myhours.Record{Start: day.Add(11 * time.Hour), CategoryID: cat}},
+		{name: "no start", record: myhours.Record{End: day.Add(11 * time.Hour), CategoryID: cat}},
+		{name: "unknown category", record: myhours.Record{Start: day.Add(11 * time.Hour), End: day.Add(12 * time.Hour), CategoryID: 1_000_000}},
+	}
+	for _, tt := range tests {
+		if _, err := db.ImportRecords(ctx, []myhours.Record{valid, tt.record}); err == nil {
+			t.Errorf("ImportRecords() with %s record, want error", tt.name)
+		}
+	}
+	records, err := db.Records(ctx, day, day.AddDate(0, 0, 1))
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("Records() after failed imports = %v, want none", records)
+	}
+}
+
+func testUpdateRecord(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, other := categories(t, db)
+	start := day.Add(9 * time.Hour)
+	id, err := db.StartRecord(ctx, start, cat, "started")
+	if err != nil {
+		t.Fatalf("StartRecord() error = %v", err)
+	}
+	if err = db.PauseRecord(ctx, id, start.Add(time.Hour)); err != nil {
+		t.Fatalf("PauseRecord() error = %v", err)
+	}
+	end := start.Add(2 * time.Hour)
+	if err = db.UpdateRecord(ctx, id, other, start.Add(-time.Hour), end, "updated"); err != nil {
+		t.Fatalf("UpdateRecord() error = %v", err)
+	}
+	got := mustRecord(t, db, id)
+	if got.CategoryID != other || got.Notes != "updated" || !got.Start.Equal(start.Add(-time.Hour)) || !got.End.Equal(end) {
+		t.Errorf("updated record = %+v", got)
+	}
+	if len(got.Breaks) != 1 || !got.Breaks[0].End.Equal(end) {
+		t.Errorf("updated record breaks = %v, want break ended at %v", got.Breaks, end)
+	}
+	if active, _ := db.ActiveRecord(ctx); active != nil {
+		t.Errorf("ActiveRecord() = %+v after ending the record, want nil", active)
+	}
+	if err = db.UpdateRecord(ctx, id+100, cat, start, end, ""); err == nil {
+		t.Error("UpdateRecord() of unknown record, want error")
+	}
+}
+
+func testPauseRecord(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, _ := categories(t, db)
+	start := day.Add(9 * time.Hour)
+	finished := mustImport(t, db, myhours.Record{Start: start.Add(-2 * time.Hour), End: start.Add(-time.Hour), CategoryID: cat})[0]
+	if err := db.PauseRecord(ctx, finished, start); err == nil {
+		t.Error("PauseRecord() of a finished record, want error")
+	}
+	id, err := db.StartRecord(ctx, start, cat, "")
+	if err != nil {
+		t.Fatalf("StartRecord() error = %v", err)
+	}
+	if err = db.ResumeRecord(ctx, id, start.Add(time.Minute)); err == nil {
+		t.Error("ResumeRecord() of a record not paused, want error")
+	}
+	if err = db.PauseRecord(ctx, id, start.Add(time.Hour)); err != nil {
+		t.Fatalf("PauseRecord() error = %v", err)
+	}
+	if err = db.PauseRecord(ctx, id, start.Add(time.Hour)); err == nil {
+		t.Error("PauseRecord() of a paused record, want error")
+	}
+	if active, _ := db.ActiveRecord(ctx); active == nil || !active.Paused() {
+		t.Errorf("ActiveRecord() = %+v, want paused record", active)
+	}
+	if err = db.ResumeRecord(ctx, id, start.Add(90*time.Minute)); err != nil {
+		t.Fatalf("ResumeRecord() error = %v", err)
+	}
+	want := []myhours.Break{{Start: start.Add(time.Hour), End: start.Add(90 * time.Minute)}}
+	got := mustRecord(t, db, id).Breaks
+	if len(got) != 1 || !got[0].Start.Equal(want[0].Start) || !got[0].End.Equal(want[0].End) {
+		t.Errorf("breaks = %v, want %v", got, want)
+	}
+}
+
+func testCompletePomodoro(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, _ := categories(t, db)
+	start := day.Add(9 * time.Hour)
+	id, err := db.StartRecord(ctx, start, cat, "")
+	if err != nil {
+		t.Fatalf("StartRecord() error = %v", err)
+	}
+	for i := range 2 {
+		if err = db.CompletePomodoro(ctx, id, start.Add(time.Duration(i+1)*25*time.Minute)); err != nil {
+			t.Fatalf("CompletePomodoro() error = %v", err)
+		}
+	}
+	if got := mustRecord(t, db, id).Pomodoros; got != 2 {
+		t.Errorf("Pomodoros = %d, want 2", got)
+	}
+	if err = db.CompletePomodoro(ctx, id+100, start); err == nil {
+		t.Error("CompletePomodoro() of unknown record, want error")
+	}
+}
+
+// testLocalTime checks that times stored in any location are returned as the
+// same instants, in local time.
+func testLocalTime(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	cat, _ := categories(t, db)
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	start := time.Date(2026, 1, 5, 9, 0, 0, 0, zone)
+	ids := mustImport(t, db, myhours.Record{
+		Start:      start,
+		End:        start.Add(2 * time.Hour),
+		CategoryID: cat,
+		Breaks:     []myhours.Break{{Start: start.Add(time.Hour), End: start.Add(90 * time.Minute)}},
+	})
+	got := mustRecord(t, db, ids[0])
+	times := []struct {
+		name      string
+		got, want time.Time
+	}{
+		{name: "start", got: got.Start, want: start},
+		{name: "end", got: got.End, want: start.Add(2 * time.Hour)},
+		{name: "break start", got: got.Breaks[0].Start, want: start.Add(time.Hour)},
+		{name: "break end", got: got.Breaks[0].End, want: start.Add(90 * time.Minute)},
+	}
+	for _, tt := range times {
+		if !tt.got.Equal(tt.want) || tt.got.Location() != time.Local {
+			t.Errorf("%s = %v, want %v in local time", tt.name, tt.got, tt.want.Local())
+		}
+	}
+	// records are found by their instant, regardless of location.
+	records, err := db.Records(ctx, start.UTC(), start.UTC().Add(time.Minute))
+	if err != nil || len(records) != 1 {
+		t.Errorf("Records() around start in UTC = %v, %v, want the record", records, err)
+	}
+}
+
+func testCategories(t *testing.T, db myhours.Database) {
+	categories, err := db.Categories(context.Background())
+	if err != nil {
+		t.Fatalf("Categories() error = %v", err)
+	}
+	for i, cat := range categories {
+		if cat.ID == 0 || cat.Name == "" {
+			t.Errorf("Categories()[%d] = %+v, want ID and name", i, cat)
+		}
+		if i > 0 && cat.ID <= categories[i-1].ID {
+			t.Errorf("Categories() not ordered by ID: %v", categories)
+		}
+	}
+}
+
+func testSettings(t *testing.T, db myhours.Database) {
+	ctx := context.Background()
+	_, other := categories(t, db)
+	settings, err := db.Settings(ctx)
+	if err != nil {
+		t.Fatalf("Settings() error = %v", err)
+	}
+	if defaults := myhours.DefaultSettings(); settings.ForgottenTimerThreshold != defaults.ForgottenTimerThreshold || settings.ReportShowBreaks != defaults.ReportShowBreaks {
+		t.Errorf("Settings() = %+v, want defaults %+v", settings, defaults)
+	}
+	values := map[myhours.Setting]string{
+		myhours.SettingDefaultCategory:         strconv.FormatInt(other, 10),
+		myhours.SettingForgottenTimerThreshold: "8h0m0s",
+		myhours.SettingReportShowBreaks:        "true",
+		myhours.SettingPinnedTasks:             fmt.Sprintf(`[{"category":%d,"notes":"standup"}]`, other),
+	}
+	for key, value := range values {
+		if err = db.UpdateSetting(ctx, key, value); err != nil {
+			t.Fatalf("UpdateSetting(%s) error = %v", key, err)
+		}
+	}
+	if err = db.UpdateSetting(ctx, myhours.SettingForgottenTimerThreshold, "soon"); err == nil {
+		t.Error("UpdateSetting() with invalid value, want error")
+	}
+	if settings, err = db.Settings(ctx); err != nil {
+		t.Fatalf("Settings() error = %v", err)
+	}
+	for _, spec := range myhours.SettingSpecs() {
+		if got := spec.Format(*settings); got != values[spec.Key] {
+			t.Errorf("Settings() %s = %q, want %q", spec.Key, got, values[spec.Key])
+		}
+	}
+}
+
+func testCancelled(t *testing.T, db myhours.Database) {
+	cat, _ := categories(t, db)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.Records(ctx, day, day.AddDate(0, 0, 1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Records() error = %v, want context.Canceled", err)
+	}
+	for _, err := range db.RecordsSeq(ctx, day, day.AddDate(0, 0, 1)) {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("RecordsSeq() error = %v, want context.Canceled", err)
+		}
+	}
+	if _, err := db.StartRecord(ctx, day, cat, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("StartRecord() error = %v, want context.Canceled", err)
+	}
+	if active, err := db.ActiveRecord(context.Background()); err != nil || active != nil {
+		t.Errorf("ActiveRecord() = %v, %v, want nothing started with cancelled context", active, err)
+	}
+}
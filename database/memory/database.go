@@ -0,0 +1,474 @@
+// Package memory implements myhours.Database in memory.
+//
+// Nothing is persisted, all data is lost when the program exits. Useful for
+// tests, and for trying out the application without a real database.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/msepp/myhours"
+)
+
+// DefaultCategories are the categories of a new database, the same as in new
+// SQLite databases.
+var DefaultCategories = []myhours.Category{
+	{ID: 1, Name: "Uncategorized", BackgroundDark: "232", ForegroundDark: "250", BackgroundLight: "254", ForegroundLight: "240"},
+	{ID: 2, Name: "Work", BackgroundDark: "232", ForegroundDark: "208", BackgroundLight: "254", ForegroundLight: "202"},
+	{ID: 3, Name: "Personal", BackgroundDark: "232", ForegroundDark: "12", BackgroundLight: "254", ForegroundLight: "4"},
+}
+
+var _ myhours.Database = (*Memory)(nil)
+
+// Memory implements Database in memory. Safe for concurrent use.
+type Memory struct {
+	mu         sync.Mutex
+	records    []myhours.Record
+	lastID     int64
+	categories []myhours.Category
+	settings   map[string]string
+}
+
+// Option defines option function for the in-memory Database implementation.
+type Option func(*Memory)
+
+// UseCategories sets the categories of the database, replacing the default
+// categories.
+func UseCategories(categories ...myhours.Category) Option {
+	return func(db *Memory) {
+		db.categories = slices.Clone(categories)
+	}
+}
+
+// NewMemory returns an empty in-memory database with the default categories.
+func NewMemory(options ...Option) *Memory {
+	db := &Memory{
+		categories: slices.Clone(DefaultCategories),
+		settings:   make(map[string]string),
+	}
+	for _, option := range options {
+		option(db)
+	}
+	return db
+}
+
+// local returns t in local time, the same way times are returned from SQLite.
+// Also strips the monotonic clock reading.
+func local(t time.Time) time.Time {
+	if t.IsZero() {
+		return time.Time{}
+	}
+	return t.In(time.Local)
+}
+
+// clone returns a copy of record that shares no data with it.
+func clone(record myhours.Record) *myhours.Record {
+	record.Breaks = slices.Clone(record.Breaks)
+	return &record
+}
+
+// find returns the index of the record identified by recordID, or -1 if there
+// is no such record. Caller must hold the lock.
+func (db *Memory) find(recordID int64) int {
+	return slices.IndexFunc(db.records, func(r myhours.Record) bool { return r.ID == recordID })
+}
+
+// active returns the index of the active record, or -1 if no record is active.
+// Caller must hold the lock.
+func (db *Memory) active() int {
+	for i := len(db.records) - 1; i >= 0; i-- {
+		if !db.records[i].Finished() {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkCategory returns an error if there is no category matching categoryID.
+// Caller must hold the lock.
+func (db *Memory) checkCategory(categoryID int64) error {
+	if !slices.ContainsFunc(db.categories, func(c myhours.Category) bool { return c.ID == categoryID }) {
+		return fmt.Errorf("unknown category %d", categoryID)
+	}
+	return nil
+}
+
+// insert adds the record with a new ID, and returns the ID. Caller must hold
+// the lock.
+func (db *Memory) insert(record myhours.Record) int64 {
+	db.lastID++
+	record.ID = db.lastID
+	record.Start = local(record.Start)
+	record.End = local(record.End)
+	record.Breaks = slices.Clone(record.Breaks)
+	for i := range record.Breaks {
+		record.Breaks[i].Start = local(record.Breaks[i].Start)
+		record.Breaks[i].End = local(record.Breaks[i].End)
+	}
+	db.records = append(db.records, record)
+	return record.ID
+}
+
+// ActiveRecord returns the record that has no end time set yet.
+func (db *Memory) ActiveRecord(ctx context.Context) (*myhours.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if i := db.active(); i >= 0 {
+		return clone(db.records[i]), nil
+	}
+	return nil, nil
+}
+
+// Record returns the record matching given ID.
+func (db *Memory) Record(ctx context.Context, recordID int64) (*myhours.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if i := db.find(recordID); i >= 0 {
+		return clone(db.records[i]), nil
+	}
+	return nil, nil
+}
+
+// LastRecord returns the finished record that ended last.
+func (db *Memory) LastRecord(ctx context.Context) (*myhours.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var last *myhours.Record
+	for i, record := range db.records {
+		if record.Finished() && (last == nil || record.End.After(last.End)) {
+			last = &db.records[i]
+		}
+	}
+	if last == nil {
+		return nil, nil
+	}
+	return clone(*last), nil
+}
+
+// Records returns the records for given timestamps [from, before), by start
+// time.
+func (db *Memory) Records(ctx context.Context, from, before time.Time) ([]myhours.Record, error) {
+	return db.filter(ctx, func(r myhours.Record) bool {
+		return !r.Start.Before(from) && r.Start.Before(before)
+	})
+}
+
+// RecordsInCategory returns the records for given timestamps [from, before)
+// that have the given category.
+func (db *Memory) RecordsInCategory(ctx context.Context, from, before time.Time, categoryID int64) ([]myhours.Record, error) {
+	return db.filter(ctx, func(r myhours.Record) bool {
+		return r.CategoryID == categoryID && !r.Start.Before(from) && r.Start.Before(before)
+	})
+}
+
+// RecordsSeq iterates the records for given timestamps [from, before), by
+// start time. Records are copied when iteration starts, changes made during
+// iteration are not seen.
+func (db *Memory) RecordsSeq(ctx context.Context, from, before time.Time) iter.Seq2[myhours.Record, error] {
+	return db.filterSeq(ctx, func(r myhours.Record) bool {
+		return !r.Start.Before(from) && r.Start.Before(before)
+	})
+}
+
+// RecordsInCategorySeq iterates the records for given timestamps [from,
+// before) that have the given category, like RecordsSeq.
+func (db *Memory) RecordsInCategorySeq(ctx context.Context, from, before time.Time, categoryID int64) iter.Seq2[myhours.Record, error] {
+	return db.filterSeq(ctx, func(r myhours.Record) bool {
+		return r.CategoryID == categoryID && !r.Start.Before(from) && r.Start.Before(before)
+	})
+}
+
+// Aggregate returns the totals of finished records selected by the query,
+// grouped as the query says.
+func (db *Memory) Aggregate(ctx context.Context, q myhours.AggregateQuery) ([]myhours.Aggregate, error) {
+	records, err := db.Records(ctx, q.From, q.Before)
+	if err != nil {
+		return nil, err
+	}
+	return myhours.AggregateRecords(records, q), nil
+}
+
+// filterSeq iterates the records matching match, ordered by start time.
+func (db *Memory) filterSeq(ctx context.Context, match func(myhours.Record) bool) iter.Seq2[myhours.Record, error] {
+	return func(yield func(myhours.Record, error) bool) {
+		records, err := db.filter(ctx, match)
+		if err != nil {
+			yield(myhours.Record{}, err)
+			return
+		}
+		for _, record := range records {
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// filter returns the records matching match, ordered by start time.
+func (db *Memory) filter(ctx context.Context, match func(myhours.Record) bool) ([]myhours.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var records []myhours.Record
+	for _, record := range db.records {
+		if match(record) {
+			records = append(records, *clone(record))
+		}
+	}
+	slices.SortStableFunc(records, func(a, b myhours.Record) int { return a.Start.Compare(b.Start) })
+	return records, nil
+}
+
+// RecentTasks returns up to limit distinct category and notes combinations,
+// most recently started first.
+func (db *Memory) RecentTasks(ctx context.Context, limit int) ([]myhours.Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	last := make(map[myhours.Task]time.Time)
+	for _, record := range db.records {
+		task := myhours.Task{CategoryID: record.CategoryID, Notes: record.Notes}
+		if record.Start.After(last[task]) {
+			last[task] = record.Start
+		}
+	}
+	tasks := make([]myhours.Task, 0, len(last))
+	for task := range last {
+		tasks = append(tasks, task)
+	}
+	slices.SortFunc(tasks, func(a, b myhours.Task) int {
+		// ties are broken by task, map iteration order is random.
+		return cmp.Or(last[b].Compare(last[a]), cmp.Compare(a.CategoryID, b.CategoryID), cmp.Compare(a.Notes, b.Notes))
+	})
+	return tasks[:min(max(limit, 0), len(tasks))], nil
+}
+
+// ImportRecords adds the given records. All records are validated before any
+// are added, so the result is all or nothing.
+//
+// Returns the IDs of created records.
+func (db *Memory) ImportRecords(ctx context.Context, records []myhours.Record) ([]int64, error) {
+	for _, record := range records {
+		if !record.Finished() {
+			return nil, errors.New("all records must be finished")
+		}
+		if err := record.Validate(); err != nil {
+			return nil, fmt.Errorf("validate record: %w", err)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, record := range records {
+		if err := db.checkCategory(record.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+	ids := make([]int64, 0, len(records))
+	for _, record := range records {
+		record.Breaks = slices.Clone(record.Breaks)
+		for i := range record.Breaks {
+			if record.Breaks[i].End.IsZero() {
+				record.Breaks[i].End = record.End
+			}
+		}
+		ids = append(ids, db.insert(record))
+	}
+	return ids, nil
+}
+
+// StartRecord adds a new active record. Returns an error if a record is active
+// already.
+func (db *Memory) StartRecord(ctx context.Context, start time.Time, categoryID int64, notes string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if db.active() >= 0 {
+		return 0, errors.New("active record already exists")
+	}
+	if err := db.checkCategory(categoryID); err != nil {
+		return 0, err
+	}
+	return db.insert(myhours.Record{Start: start, CategoryID: categoryID, Notes: notes}), nil
+}
+
+// SwitchRecord ends the active record at end, if any, and adds a new active
+// record starting at start. A break still on for the ended record is ended as
+// well.
+func (db *Memory) SwitchRecord(ctx context.Context, end, start time.Time, categoryID int64, notes string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if err := db.checkCategory(categoryID); err != nil {
+		return 0, err
+	}
+	if start.Before(end) {
+		return 0, errors.New("new record can not start before the active record ends")
+	}
+	if i := db.active(); i >= 0 {
+		if !end.After(db.records[i].Start) {
+			return 0, errors.New("switch time must be after start of active record")
+		}
+		endRecord(&db.records[i], end)
+	}
+	return db.insert(myhours.Record{Start: start, CategoryID: categoryID, Notes: notes}), nil
+}
+
+// endRecord sets the end time of the record, and of any break still on.
+func endRecord(record *myhours.Record, at time.Time) {
+	record.End = local(at)
+	for i := range record.Breaks {
+		if record.Breaks[i].End.IsZero() {
+			record.Breaks[i].End = record.End
+		}
+	}
+}
+
+// UpdateRecord sets the details of the record matching recordID. Ends any open
+// break of the record if end time is set.
+func (db *Memory) UpdateRecord(ctx context.Context, recordID int64, categoryID int64, start, end time.Time, notes string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i := db.find(recordID)
+	if i < 0 {
+		return fmt.Errorf("record %d not found", recordID)
+	}
+	if err := db.checkCategory(categoryID); err != nil {
+		return err
+	}
+	record := &db.records[i]
+	record.CategoryID = categoryID
+	record.Start = local(start)
+	record.Notes = notes
+	record.End = time.Time{}
+	if !end.IsZero() {
+		endRecord(record, end)
+	}
+	return nil
+}
+
+// PauseRecord starts a break for the active record matching recordID.
+func (db *Memory) PauseRecord(ctx context.Context, recordID int64, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i := db.find(recordID)
+	switch {
+	case i < 0:
+		return fmt.Errorf("record %d not found", recordID)
+	case !db.records[i].Active():
+		return errors.New("record is not active")
+	case db.records[i].Paused():
+		return errors.New("record is already paused")
+	}
+	db.records[i].Breaks = append(db.records[i].Breaks, myhours.Break{Start: local(at)})
+	return nil
+}
+
+// ResumeRecord ends the open break of the record matching recordID.
+func (db *Memory) ResumeRecord(ctx context.Context, recordID int64, at time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i := db.find(recordID)
+	if i < 0 {
+		return errors.New("record is not paused")
+	}
+	breaks := db.records[i].Breaks
+	if len(breaks) == 0 || !breaks[len(breaks)-1].End.IsZero() {
+		return errors.New("record is not paused")
+	}
+	breaks[len(breaks)-1].End = local(at)
+	return nil
+}
+
+// CompletePomodoro registers a completed pomodoro for the record matching
+// recordID.
+func (db *Memory) CompletePomodoro(ctx context.Context, recordID int64, _ time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	i := db.find(recordID)
+	if i < 0 {
+		return fmt.Errorf("record %d not found", recordID)
+	}
+	db.records[i].Pomodoros++
+	return nil
+}
+
+// Categories returns all categories, ordered by ID.
+func (db *Memory) Categories(ctx context.Context) ([]myhours.Category, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	categories := slices.Clone(db.categories)
+	slices.SortFunc(categories, func(a, b myhours.Category) int { return cmp.Compare(a.ID, b.ID) })
+	return categories, nil
+}
+
+// UpdateSetting sets value of a setting identified by key. The setting is
+// created if it doesn't exist yet.
+func (db *Memory) UpdateSetting(ctx context.Context, key myhours.Setting, value string) error {
+	if err := myhours.ValidateSetting(key, value); err != nil {
+		return fmt.Errorf("myhours.ValidateSetting: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.settings[key.String()] = value
+	return nil
+}
+
+// Settings returns the full application settings. Settings that have not
+// been stored have their default values.
+func (db *Memory) Settings(ctx context.Context) (*myhours.Settings, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	settings, err := myhours.ParseSettings(db.settings)
+	if err != nil {
+		return nil, fmt.Errorf("myhours.ParseSettings: %w", err)
+	}
+	return &settings, nil
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/msepp/myhours"
+	"github.com/msepp/myhours/database/databasetest"
+)
+
+func TestMemory(t *testing.T) {
+	databasetest.Run(t, func(*testing.T) myhours.Database { return NewMemory() })
+}
+
+// TestMemory_shared checks that records read from the memory backend share no
+// data with the records it keeps.
+func TestMemory_shared(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		db    = NewMemory()
+		start = time.Date(2026, 1, 5, 9, 0, 0, 0, time.Local)
+	)
+	ids, err := db.ImportRecords(ctx, []myhours.Record{{
+		Start: start, End: start.Add(time.Hour), CategoryID: 2,
+		Breaks: []myhours.Break{{Start: start.Add(10 * time.Minute), End: start.Add(20 * time.Minute)}},
+	}})
+	if err != nil {
+		t.Fatalf("ImportRecords() error = %v", err)
+	}
+	record, err := db.Record(ctx, ids[0])
+	if err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	record.Breaks[0].End = start.Add(time.Hour)
+	records, err := db.Records(ctx, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	records[0].Breaks[0].End = start.Add(time.Hour)
+	if record, _ = db.Record(ctx, ids[0]); !record.Breaks[0].End.Equal(start.Add(20 * time.Minute)) {
+		t.Errorf("break = %+v after modifying read records, want unchanged", record.Breaks[0])
+	}
+}
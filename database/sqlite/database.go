@@ -5,14 +5,18 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	_ "embed"
 	"errors"
 	"fmt"
+	"iter"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/glebarez/go-sqlite"
@@ -22,29 +26,85 @@ import (
 //go:embed default.db
 var defaultDB []byte
 
+// migrations bring existing databases up to date with the current schema. The
+// statements must be safe to run on every start.
+//
+//go:embed migrations.sql
+var migrations string
+
+// Times are stored as RFC3339 text. Compare them with julianday, since the
+// text doesn't sort by time when fractions of seconds or offsets differ.
 const (
-	selectFullRecord       = `SELECT "id", "start", "end", "category", "notes" FROM records`
+	selectFullRecord       = `SELECT "id", "start", "end", "category", "notes", (SELECT COUNT(*) FROM pomodoros WHERE "record" = records."id") FROM records`
 	queryActiveRecord      = selectFullRecord + ` WHERE "end" IS NULL ORDER BY id DESC LIMIT 1`
 	queryRecord            = selectFullRecord + ` WHERE "id" = $1`
-	queryRecords           = selectFullRecord + ` WHERE "start" > $1 AND "end" < $2 ORDER BY start ASC`
-	queryRecordsOfCategory = selectFullRecord + ` WHERE "start" > $1 AND "end" < $2 AND "category" = $3 ORDER BY start ASC`
+	queryLastRecord        = selectFullRecord + ` WHERE "end" IS NOT NULL ORDER BY julianday("end") DESC LIMIT 1`
+	queryRecords           = selectFullRecord + ` WHERE julianday("start") >= julianday($1) AND julianday("start") < julianday($2) ORDER BY julianday("start") ASC, "id" ASC`
+	queryRecordsOfCategory = selectFullRecord + ` WHERE julianday("start") >= julianday($1) AND julianday("start") < julianday($2) AND "category" = $3 ORDER BY julianday("start") ASC, "id" ASC`
+	queryRecentTasks       = `SELECT "category", COALESCE("notes", '') AS "task_notes", MAX(julianday("start")) AS "last" FROM records GROUP BY "category", "task_notes" ORDER BY "last" DESC LIMIT $1`
 	queryCategories        = `SELECT "id", "name", "color_dark_bg", "color_dark_fg", "color_light_bg", "color_light_fg" FROM categories ORDER BY "id" ASC`
 	insertFullRecord       = `INSERT INTO records ("start", "end", "category", "notes") VALUES ($1, $2, $3, $4) RETURNING id`
 	insertActiveRecord     = `INSERT INTO records ("start", "category", "notes") VALUES ($1, $2, $3) RETURNING "id"`
+	endRecord              = `UPDATE records SET "end" = $2 WHERE "id" = $1`
 	updateRecord           = `UPDATE records SET "category" = $2, "start" = $3, "end" = $4, "notes" = $5 WHERE "id" = $1`
+	queryBreaks            = `SELECT "record", "start", "end" FROM breaks WHERE "record" IN (%s) ORDER BY "start" ASC`
+	queryOpenBreak         = `SELECT "id" FROM breaks WHERE "record" = $1 AND "end" IS NULL`
+	insertBreak            = `INSERT INTO breaks ("record", "start") VALUES ($1, $2)`
+	insertFullBreak        = `INSERT INTO breaks ("record", "start", "end") VALUES ($1, $2, $3)`
+	endBreak               = `UPDATE breaks SET "end" = $2 WHERE "id" = $1`
+	endOpenBreaks          = `UPDATE breaks SET "end" = $2 WHERE "record" = $1 AND "end" IS NULL`
+	insertPomodoro         = `INSERT INTO pomodoros ("record", "completed") VALUES ($1, $2)`
 	queryConfigSettings    = `SELECT "key", "value" FROM configuration`
-	updateConfigSetting    = `UPDATE configuration SET "value" = $2 WHERE "key" = $1`
+	upsertConfigSetting    = `INSERT INTO configuration ("key", "value") VALUES ($1, $2) ON CONFLICT ("key") DO UPDATE SET "value" = excluded."value"`
+)
+
+// Pages of records continue after the start time ($1) and ID ($2) of the last
+// record of the previous page, in the order of queryRecords. Start from the
+// beginning of the range with ID zero.
+const (
+	queryRecordsPage           = selectFullRecord + ` WHERE (julianday("start") > julianday($1) OR (julianday("start") = julianday($1) AND "id" > $2)) AND julianday("start") < julianday($3) ORDER BY julianday("start") ASC, "id" ASC LIMIT $4`
+	queryRecordsOfCategoryPage = selectFullRecord + ` WHERE (julianday("start") > julianday($1) OR (julianday("start") = julianday($1) AND "id" > $2)) AND julianday("start") < julianday($3) AND "category" = $5 ORDER BY julianday("start") ASC, "id" ASC LIMIT $4`
 )
 
+// queryAggregate sums up finished records by the periods listed as values of
+// ("period", "from", "before"), and by category if $4 is true. Durations are
+// summed in milliseconds. Breaks are counted until the end of the record, like
+// myhours.Break.Duration does. Periods are given by the caller, so that they follow
+// the local time zone of the application instead of the one of SQLite.
+const queryAggregate = `WITH "periods" ("period", "from", "before") AS (VALUES %s),
+"totals" AS (
+	SELECT records."start", records."category",
+		CAST(ROUND((julianday(records."end") - julianday(records."start")) * 86400000) AS INTEGER) AS "span",
+		COALESCE((
+			SELECT SUM(MAX(0, CAST(ROUND((MIN(julianday(COALESCE(breaks."end", records."end")), julianday(records."end")) - julianday(breaks."start")) * 86400000) AS INTEGER)))
+			FROM breaks WHERE breaks."record" = records."id"
+		), 0) AS "breaks",
+		(SELECT COUNT(*) FROM pomodoros WHERE pomodoros."record" = records."id") AS "pomodoros"
+	FROM records
+	WHERE records."end" IS NOT NULL AND ($1 = 0 OR records."category" = $1)
+		AND julianday(records."start") >= julianday($2) AND julianday(records."start") < julianday($3)
+)
+SELECT "periods"."period", CASE WHEN $4 THEN "totals"."category" ELSE 0 END AS "group_category",
+	COUNT(*), SUM("totals"."span" - "totals"."breaks"), SUM("totals"."breaks"), SUM("totals"."pomodoros")
+FROM "periods" JOIN "totals"
+	ON julianday("totals"."start") >= julianday("periods"."from") AND julianday("totals"."start") < julianday("periods"."before")
+GROUP BY "periods"."period", "group_category"
+ORDER BY "periods"."period" ASC, "group_category" ASC`
+
+// defaultPageSize is the number of records read at a time when iterating
+// records.
+const defaultPageSize = 500
+
 // SQLite implements Database on top of SQLite.
 type SQLite struct {
-	db *sql.DB
-	l  *slog.Logger
+	db       *sql.DB
+	l        *slog.Logger
+	pageSize int
 }
 
 // ActiveRecord finds the first myhours.Record from database that has no end time set yet.
-func (db *SQLite) ActiveRecord() (*myhours.Record, error) {
-	res := db.db.QueryRow(queryActiveRecord)
+func (db *SQLite) ActiveRecord(ctx context.Context) (*myhours.Record, error) {
+	res := db.db.QueryRowContext(ctx, queryActiveRecord)
 	record, err := scanRecord(res)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -52,12 +112,15 @@ func (db *SQLite) ActiveRecord() (*myhours.Record, error) {
 		}
 		return nil, fmt.Errorf("scanDBRecordRow: %w", err)
 	}
+	if err = db.loadBreaks(ctx, record); err != nil {
+		return nil, fmt.Errorf("load breaks: %w", err)
+	}
 	return record, nil
 }
 
 // Record retrieves a myhours.Record matching given ID.
-func (db *SQLite) Record(id int64) (*myhours.Record, error) {
-	res := db.db.QueryRow(queryRecord, id)
+func (db *SQLite) Record(ctx context.Context, id int64) (*myhours.Record, error) {
+	res := db.db.QueryRowContext(ctx, queryRecord, id)
 	record, err := scanRecord(res)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -65,12 +128,56 @@ func (db *SQLite) Record(id int64) (*myhours.Record, error) {
 		}
 		return nil, fmt.Errorf("scanDBRecordRow: %w", err)
 	}
+	if err = db.loadBreaks(ctx, record); err != nil {
+		return nil, fmt.Errorf("load breaks: %w", err)
+	}
+	return record, nil
+}
+
+// LastRecord retrieves the finished myhours.Record that ended last.
+func (db *SQLite) LastRecord(ctx context.Context) (*myhours.Record, error) {
+	res := db.db.QueryRowContext(ctx, queryLastRecord)
+	record, err := scanRecord(res)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scanDBRecordRow: %w", err)
+	}
+	if err = db.loadBreaks(ctx, record); err != nil {
+		return nil, fmt.Errorf("load breaks: %w", err)
+	}
 	return record, nil
 }
 
+// RecentTasks retrieves up to limit distinct category and notes combinations,
+// most recently started first.
+func (db *SQLite) RecentTasks(ctx context.Context, limit int) ([]myhours.Task, error) {
+	res, err := db.db.QueryContext(ctx, queryRecentTasks, limit)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer func() { _ = res.Close() }()
+	var tasks []myhours.Task
+	for res.Next() {
+		var (
+			task myhours.Task
+			last float64
+		)
+		if err = res.Scan(&task.CategoryID, &task.Notes, &last); err != nil {
+			return nil, fmt.Errorf("res.Scan: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err = res.Err(); err != nil {
+		return nil, fmt.Errorf("res.Err: %w", err)
+	}
+	return tasks, nil
+}
+
 // Records retrieves records for given timestamps [from, before).
-func (db *SQLite) Records(from, before time.Time) ([]myhours.Record, error) {
-	res, err := db.db.Query(queryRecords, from.In(time.UTC).Format(time.RFC3339Nano), before.In(time.UTC).Format(time.RFC3339Nano))
+func (db *SQLite) Records(ctx context.Context, from, before time.Time) ([]myhours.Record, error) {
+	res, err := db.db.QueryContext(ctx, queryRecords, from.In(time.UTC).Format(time.RFC3339Nano), before.In(time.UTC).Format(time.RFC3339Nano))
 	if err != nil {
 		return nil, fmt.Errorf("db.Query: %w", err)
 	}
@@ -84,13 +191,16 @@ func (db *SQLite) Records(from, before time.Time) ([]myhours.Record, error) {
 		}
 		records = append(records, *record)
 	}
+	if err = db.loadBreaks(ctx, pointers(records)...); err != nil {
+		return nil, fmt.Errorf("load breaks: %w", err)
+	}
 	return records, nil
 }
 
 // RecordsInCategory  retrieves records for given timestamps [from, before) that
 // have the given category.
-func (db *SQLite) RecordsInCategory(from, before time.Time, categoryID int64) ([]myhours.Record, error) {
-	res, err := db.db.Query(queryRecordsOfCategory, from.In(time.UTC).Format(time.RFC3339Nano), before.In(time.UTC).Format(time.RFC3339Nano), categoryID)
+func (db *SQLite) RecordsInCategory(ctx context.Context, from, before time.Time, categoryID int64) ([]myhours.Record, error) {
+	res, err := db.db.QueryContext(ctx, queryRecordsOfCategory, from.In(time.UTC).Format(time.RFC3339Nano), before.In(time.UTC).Format(time.RFC3339Nano), categoryID)
 	if err != nil {
 		return nil, fmt.Errorf("db.Query: %w", err)
 	}
@@ -104,16 +214,130 @@ func (db *SQLite) RecordsInCategory(from, before time.Time, categoryID int64) ([
 		}
 		records = append(records, *record)
 	}
+	if err = db.loadBreaks(ctx, pointers(records)...); err != nil {
+		return nil, fmt.Errorf("load breaks: %w", err)
+	}
+	return records, nil
+}
+
+// RecordsSeq iterates records for given timestamps [from, before). Records are
+// read a page at a time, so the query doesn't hold the database for the whole
+// iteration.
+func (db *SQLite) RecordsSeq(ctx context.Context, from, before time.Time) iter.Seq2[myhours.Record, error] {
+	return db.recordPages(ctx, from, before, queryRecordsPage)
+}
+
+// RecordsInCategorySeq iterates records for given timestamps [from, before)
+// that have the given category, like RecordsSeq.
+func (db *SQLite) RecordsInCategorySeq(ctx context.Context, from, before time.Time, categoryID int64) iter.Seq2[myhours.Record, error] {
+	return db.recordPages(ctx, from, before, queryRecordsOfCategoryPage, categoryID)
+}
+
+// recordPages iterates the records of [from, before) read with a page query.
+// Pages continue after the start time and ID of the last record of the
+// previous page, args are passed to the query after those of the page.
+func (db *SQLite) recordPages(ctx context.Context, from, before time.Time, query string, args ...any) iter.Seq2[myhours.Record, error] {
+	return func(yield func(myhours.Record, error) bool) {
+		after, afterID := from, int64(0)
+		for {
+			records, err := db.recordPage(ctx, query, append([]any{
+				after.In(time.UTC).Format(time.RFC3339Nano),
+				afterID,
+				before.In(time.UTC).Format(time.RFC3339Nano),
+				db.pageSize,
+			}, args...)...)
+			if err != nil {
+				yield(myhours.Record{}, err)
+				return
+			}
+			for _, record := range records {
+				if !yield(record, nil) {
+					return
+				}
+			}
+			if len(records) < db.pageSize {
+				return
+			}
+			after, afterID = records[len(records)-1].Start, records[len(records)-1].ID
+		}
+	}
+}
+
+// recordPage reads a page of records, with their breaks.
+func (db *SQLite) recordPage(ctx context.Context, query string, args ...any) ([]myhours.Record, error) {
+	res, err := db.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer func() { _ = res.Close() }()
+	var records []myhours.Record
+	for res.Next() {
+		var record *myhours.Record
+		if record, err = scanRecord(res); err != nil {
+			return nil, fmt.Errorf("scan record: %w", err)
+		}
+		records = append(records, *record)
+	}
+	if err = res.Err(); err != nil {
+		return nil, fmt.Errorf("res.Err: %w", err)
+	}
+	if err = db.loadBreaks(ctx, pointers(records)...); err != nil {
+		return nil, fmt.Errorf("load breaks: %w", err)
+	}
 	return records, nil
 }
 
+// Aggregate returns the totals of finished records selected by the query,
+// grouped as the query says. Records are grouped in the database, only the
+// totals are read.
+func (db *SQLite) Aggregate(ctx context.Context, q myhours.AggregateQuery) ([]myhours.Aggregate, error) {
+	bounds := q.Periods()
+	if len(bounds) < 2 {
+		return nil, nil
+	}
+	var (
+		values []string
+		args   = []any{q.CategoryID, q.From.In(time.UTC).Format(time.RFC3339Nano), q.Before.In(time.UTC).Format(time.RFC3339Nano), q.ByCategory}
+	)
+	for i := range len(bounds) - 1 {
+		n := len(args)
+		values = append(values, fmt.Sprintf("($%d, $%d, $%d)", n+1, n+2, n+3))
+		args = append(args, i, bounds[i].In(time.UTC).Format(time.RFC3339Nano), bounds[i+1].In(time.UTC).Format(time.RFC3339Nano))
+	}
+	res, err := db.db.QueryContext(ctx, fmt.Sprintf(queryAggregate, strings.Join(values, ", ")), args...)
+	if err != nil {
+		return nil, fmt.Errorf("db.Query: %w", err)
+	}
+	defer func() { _ = res.Close() }()
+	var aggregates []myhours.Aggregate
+	for res.Next() {
+		var (
+			aggregate        myhours.Aggregate
+			period           int
+			duration, breaks int64
+		)
+		if err = res.Scan(&period, &aggregate.CategoryID, &aggregate.Records, &duration, &breaks, &aggregate.Pomodoros); err != nil {
+			return nil, fmt.Errorf("res.Scan: %w", err)
+		}
+		aggregate.Start = bounds[period]
+		aggregate.Duration = time.Duration(duration) * time.Millisecond
+		aggregate.Breaks = time.Duration(breaks) * time.Millisecond
+		aggregates = append(aggregates, aggregate)
+	}
+	if err = res.Err(); err != nil {
+		return nil, fmt.Errorf("res.Err: %w", err)
+	}
+	return aggregates, nil
+}
+
 // ImportRecords inserts the given records into the database. All records are
 // validated before insert.
 //
 // Inserts are done in a transaction, so the result is all or nothing.
 //
 // Returns the IDs of created records.
-func (db *SQLite) ImportRecords(records []myhours.Record) ([]int64, error) {
+func (db *SQLite) ImportRecords(ctx context.Context, records []myhours.Record) (_ []int64, err error) {
+	defer markTransient(&err)
 	// first validate all records
 	for _, record := range records {
 		if !record.Finished() {
@@ -124,31 +348,43 @@ func (db *SQLite) ImportRecords(records []myhours.Record) ([]int64, error) {
 		}
 	}
 	// Then import in a transaction
-	tx, err := db.db.Begin()
+	tx, err := db.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, fmt.Errorf("begin transaction: %w", err)
 	}
 	var results []int64
 	for _, record := range records {
 		var res sql.Result
-		if res, err = tx.Exec(insertFullRecord,
+		if res, err = tx.ExecContext(ctx, insertFullRecord,
 			record.Start.In(time.UTC).Format(time.RFC3339Nano),
-			record.End.Format(time.RFC3339Nano),
+			record.End.In(time.UTC).Format(time.RFC3339Nano),
 			record.CategoryID,
 			ptrNonZero(record.Notes),
 		); err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				db.l.Warn("failed to rollback transaction", slog.String("error", rollbackErr.Error()))
-			}
+			db.rollback(tx)
 			return nil, fmt.Errorf("db.Exec: %w", err)
 		}
 		var id int64
 		if id, err = res.LastInsertId(); err != nil {
-			if rollbackErr := tx.Rollback(); rollbackErr != nil {
-				db.l.Warn("failed to rollback transaction", slog.String("error", rollbackErr.Error()))
-			}
+			db.rollback(tx)
 			return nil, fmt.Errorf("db.LastInsertId: %w", err)
 		}
+		for _, b := range record.Breaks {
+			end := b.End
+			if end.IsZero() {
+				end = record.End
+			}
+			if _, err = tx.ExecContext(ctx, insertFullBreak, id, b.Start.In(time.UTC).Format(time.RFC3339Nano), end.In(time.UTC).Format(time.RFC3339Nano)); err != nil {
+				db.rollback(tx)
+				return nil, fmt.Errorf("insert break: db.Exec: %w", err)
+			}
+		}
+		for range record.Pomodoros {
+			if _, err = tx.ExecContext(ctx, insertPomodoro, id, record.End.In(time.UTC).Format(time.RFC3339Nano)); err != nil {
+				db.rollback(tx)
+				return nil, fmt.Errorf("insert pomodoro: db.Exec: %w", err)
+			}
+		}
 		results = append(results, id)
 	}
 	if err = tx.Commit(); err != nil {
@@ -159,8 +395,9 @@ func (db *SQLite) ImportRecords(records []myhours.Record) ([]int64, error) {
 
 // StartRecord inserts a new myhours.Record into the database, setting only the
 // start time to indicate the record is started, but not finished.
-func (db *SQLite) StartRecord(start time.Time, categoryID int64, notes string) (int64, error) {
-	active, err := db.ActiveRecord()
+func (db *SQLite) StartRecord(ctx context.Context, start time.Time, categoryID int64, notes string) (_ int64, err error) {
+	defer markTransient(&err)
+	active, err := db.ActiveRecord(ctx)
 	if err != nil {
 		return 0, fmt.Errorf("active record: %w", err)
 	}
@@ -168,31 +405,221 @@ func (db *SQLite) StartRecord(start time.Time, categoryID int64, notes string) (
 		return 0, errors.New("active record already exists")
 	}
 	var res sql.Result
-	if res, err = db.db.Exec(insertActiveRecord, start.In(time.UTC).Format(time.RFC3339Nano), categoryID, notes); err != nil {
+	if res, err = db.db.ExecContext(ctx, insertActiveRecord, start.In(time.UTC).Format(time.RFC3339Nano), categoryID, notes); err != nil {
+		return 0, fmt.Errorf("db.Exec: %w", err)
+	}
+	var id int64
+	if id, err = res.LastInsertId(); err != nil {
+		return 0, fmt.Errorf("db.LastInsertId: %w", err)
+	}
+	return id, nil
+}
+
+// SwitchRecord ends the active myhours.Record and any break still on for it at
+// end, if any, and inserts a new active record starting at start. Done in a
+// transaction, so the result is all or nothing.
+func (db *SQLite) SwitchRecord(ctx context.Context, end, start time.Time, categoryID int64, notes string) (_ int64, err error) {
+	defer markTransient(&err)
+	if start.Before(end) {
+		return 0, errors.New("new record can not start before the active record ends")
+	}
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	active, err := scanRecord(tx.QueryRowContext(ctx, queryActiveRecord))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		// nothing to end.
+	case err != nil:
+		db.rollback(tx)
+		return 0, fmt.Errorf("scanDBRecordRow: %w", err)
+	case !end.After(active.Start):
+		db.rollback(tx)
+		return 0, errors.New("switch time must be after start of active record")
+	default:
+		endAt := end.In(time.UTC).Format(time.RFC3339Nano)
+		if _, err = tx.ExecContext(ctx, endRecord, active.ID, endAt); err != nil {
+			db.rollback(tx)
+			return 0, fmt.Errorf("end active record: db.Exec: %w", err)
+		}
+		if _, err = tx.ExecContext(ctx, endOpenBreaks, active.ID, endAt); err != nil {
+			db.rollback(tx)
+			return 0, fmt.Errorf("end open breaks: db.Exec: %w", err)
+		}
+	}
+	var res sql.Result
+	if res, err = tx.ExecContext(ctx, insertActiveRecord, start.In(time.UTC).Format(time.RFC3339Nano), categoryID, notes); err != nil {
+		db.rollback(tx)
 		return 0, fmt.Errorf("db.Exec: %w", err)
 	}
 	var id int64
 	if id, err = res.LastInsertId(); err != nil {
+		db.rollback(tx)
 		return 0, fmt.Errorf("db.LastInsertId: %w", err)
 	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
 	return id, nil
 }
 
-// UpdateRecord sets record details for the record matching recordID.
-func (db *SQLite) UpdateRecord(recordID int64, categoryID int64, start, end time.Time, notes string) error {
+// rollback the transaction, logging any failure.
+func (db *SQLite) rollback(tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil {
+		db.l.Warn("failed to rollback transaction", slog.String("error", err.Error()))
+	}
+}
+
+// UpdateRecord sets record details for the record matching recordID. Ends any
+// open break of the record if end time is set.
+func (db *SQLite) UpdateRecord(ctx context.Context, recordID int64, categoryID int64, start, end time.Time, notes string) (err error) {
+	defer markTransient(&err)
 	var endPtr *string
 	if !end.IsZero() {
 		endPtr = ptrNonZero(end.In(time.UTC).Format(time.RFC3339Nano))
 	}
-	if _, err := db.db.Exec(updateRecord, recordID, categoryID, start.In(time.UTC).Format(time.RFC3339Nano), endPtr, ptrNonZero(notes)); err != nil {
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	var res sql.Result
+	if res, err = tx.ExecContext(ctx, updateRecord, recordID, categoryID, start.In(time.UTC).Format(time.RFC3339Nano), endPtr, ptrNonZero(notes)); err != nil {
+		db.rollback(tx)
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	var updated int64
+	if updated, err = res.RowsAffected(); err != nil {
+		db.rollback(tx)
+		return fmt.Errorf("res.RowsAffected: %w", err)
+	}
+	if updated == 0 {
+		db.rollback(tx)
+		return fmt.Errorf("record %d not found", recordID)
+	}
+	if endPtr != nil {
+		if _, err = tx.ExecContext(ctx, endOpenBreaks, recordID, *endPtr); err != nil {
+			db.rollback(tx)
+			return fmt.Errorf("end open breaks: db.Exec: %w", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// PauseRecord starts a break for the active record matching recordID.
+func (db *SQLite) PauseRecord(ctx context.Context, recordID int64, at time.Time) (err error) {
+	defer markTransient(&err)
+	tx, err := db.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	record, err := scanRecord(tx.QueryRowContext(ctx, queryRecord, recordID))
+	if err != nil {
+		db.rollback(tx)
+		return fmt.Errorf("scanDBRecordRow: %w", err)
+	}
+	if !record.Active() {
+		db.rollback(tx)
+		return errors.New("record is not active")
+	}
+	var breakID int64
+	switch err = tx.QueryRowContext(ctx, queryOpenBreak, recordID).Scan(&breakID); {
+	case err == nil:
+		db.rollback(tx)
+		return errors.New("record is already paused")
+	case !errors.Is(err, sql.ErrNoRows):
+		db.rollback(tx)
+		return fmt.Errorf("query open break: %w", err)
+	}
+	if _, err = tx.ExecContext(ctx, insertBreak, recordID, at.In(time.UTC).Format(time.RFC3339Nano)); err != nil {
+		db.rollback(tx)
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// ResumeRecord ends the open break of the record matching recordID.
+func (db *SQLite) ResumeRecord(ctx context.Context, recordID int64, at time.Time) (err error) {
+	defer markTransient(&err)
+	var breakID int64
+	if err := db.db.QueryRowContext(ctx, queryOpenBreak, recordID).Scan(&breakID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("record is not paused")
+		}
+		return fmt.Errorf("query open break: %w", err)
+	}
+	if _, err := db.db.ExecContext(ctx, endBreak, breakID, at.In(time.UTC).Format(time.RFC3339Nano)); err != nil {
+		return fmt.Errorf("db.Exec: %w", err)
+	}
+	return nil
+}
+
+// CompletePomodoro inserts a completed pomodoro for the record matching recordID.
+func (db *SQLite) CompletePomodoro(ctx context.Context, recordID int64, at time.Time) (err error) {
+	defer markTransient(&err)
+	if _, err := db.db.ExecContext(ctx, insertPomodoro, recordID, at.In(time.UTC).Format(time.RFC3339Nano)); err != nil {
 		return fmt.Errorf("db.Exec: %w", err)
 	}
 	return nil
 }
 
+// breaksChunkSize is the number of records whose breaks are queried at once.
+// Each record takes a bound variable, and SQLite limits their number per
+// statement.
+const breaksChunkSize = 500
+
+// loadBreaks queries the breaks of given records and sets them to the records.
+// Breaks are queried for up to breaksChunkSize records at a time.
+func (db *SQLite) loadBreaks(ctx context.Context, records ...*myhours.Record) error {
+	for chunk := range slices.Chunk(records, breaksChunkSize) {
+		if err := db.loadBreaksOf(ctx, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBreaksOf queries the breaks of given records with a single query.
+func (db *SQLite) loadBreaksOf(ctx context.Context, records []*myhours.Record) error {
+	var (
+		byID         = make(map[int64]*myhours.Record, len(records))
+		args         = make([]any, 0, len(records))
+		placeholders = make([]string, 0, len(records))
+	)
+	for _, record := range records {
+		byID[record.ID] = record
+		args = append(args, record.ID)
+		placeholders = append(placeholders, "$"+strconv.Itoa(len(args)))
+	}
+	rows, err := db.db.QueryContext(ctx, fmt.Sprintf(queryBreaks, strings.Join(placeholders, ", ")), args...)
+	if err != nil {
+		return fmt.Errorf("db.Query: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var (
+			recordID int64
+			b        myhours.Break
+		)
+		if recordID, b, err = scanBreak(rows); err != nil {
+			return fmt.Errorf("scan break: %w", err)
+		}
+		if record, ok := byID[recordID]; ok {
+			record.Breaks = append(record.Breaks, b)
+		}
+	}
+	return rows.Err()
+}
+
 // Categories returns all myhours.Category entries.
-func (db *SQLite) Categories() ([]myhours.Category, error) {
-	rows, err := db.db.Query(queryCategories)
+func (db *SQLite) Categories(ctx context.Context) ([]myhours.Category, error) {
+	rows, err := db.db.QueryContext(ctx, queryCategories)
 	if err != nil {
 		return nil, fmt.Errorf("db.Query: %w", err)
 	}
@@ -208,36 +635,44 @@ func (db *SQLite) Categories() ([]myhours.Category, error) {
 	return result, nil
 }
 
-// UpdateSetting sets value of a setting identified by key.
-func (db *SQLite) UpdateSetting(key myhours.Setting, value string) error {
-	if _, err := db.db.Exec(updateConfigSetting, key.String(), value); err != nil {
+// UpdateSetting sets value of a setting identified by key. The setting is
+// created if it doesn't exist yet.
+func (db *SQLite) UpdateSetting(ctx context.Context, key myhours.Setting, value string) (err error) {
+	defer markTransient(&err)
+	if err := myhours.ValidateSetting(key, value); err != nil {
+		return fmt.Errorf("myhours.ValidateSetting: %w", err)
+	}
+	if _, err := db.db.ExecContext(ctx, upsertConfigSetting, key.String(), value); err != nil {
 		return fmt.Errorf("db.Exec: %w", err)
 	}
 	return nil
 }
 
-// Settings returns the full application settings.
-func (db *SQLite) Settings() (*myhours.Settings, error) {
-	rows, err := db.db.Query(queryConfigSettings)
+// Settings returns the full application settings. Settings that have not
+// been stored have their default values.
+func (db *SQLite) Settings(ctx context.Context) (*myhours.Settings, error) {
+	rows, err := db.db.QueryContext(ctx, queryConfigSettings)
 	if err != nil {
 		return nil, fmt.Errorf("db.Query: %w", err)
 	}
 	defer func() { _ = rows.Close() }()
-	var config myhours.Settings
+	values := make(map[string]string)
 	for rows.Next() {
 		var key, value string
 		if err = rows.Scan(&key, &value); err != nil {
 			return nil, fmt.Errorf("rows.Scan: %w", err)
 		}
-		switch myhours.Setting(key) {
-		case myhours.SettingDefaultCategory:
-			config.DefaultCategoryID, err = strconv.ParseInt(value, 10, 64)
-			if err != nil {
-				return nil, fmt.Errorf(key+": strconv.Atoi: %w", err)
-			}
-		default:
-			db.l.Warn("unsupported configuration key", slog.String("key", key))
-		}
+		values[key] = value
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows.Err: %w", err)
+	}
+	config, err := myhours.ParseSettings(values)
+	if err != nil {
+		return nil, fmt.Errorf("myhours.ParseSettings: %w", err)
+	}
+	for key := range config.Unknown {
+		db.l.Warn("unsupported configuration key", slog.String("key", key))
 	}
 	return &config, nil
 }
@@ -252,6 +687,16 @@ func Logger(l *slog.Logger) Option {
 	}
 }
 
+// PageSize sets the number of records read at a time when iterating records.
+// Values less than one are ignored.
+func PageSize(n int) Option {
+	return func(db *SQLite) {
+		if n > 0 {
+			db.pageSize = n
+		}
+	}
+}
+
 // InitiateSQLiteDatabase opens or creates an SQLite database to given destination.
 //
 // If no database exists in the given location, new database is initialized and
@@ -280,6 +725,10 @@ func InitiateSQLiteDatabase(dbFile string) (*sql.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("open database: %w", err)
 	}
+	if _, err = db.Exec(migrations); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("migrate database: %w", err)
+	}
 	return db, nil
 }
 
@@ -287,7 +736,7 @@ func InitiateSQLiteDatabase(dbFile string) (*sql.DB, error) {
 //
 // Use for example InitiateSQLiteDatabase to open/initiate an SQLite based *sql.DB handle.
 func NewSQLite(handle *sql.DB, options ...Option) *SQLite {
-	db := &SQLite{db: handle, l: slog.New(slog.DiscardHandler)}
+	db := &SQLite{db: handle, l: slog.New(slog.DiscardHandler), pageSize: defaultPageSize}
 	for _, option := range options {
 		option(db)
 	}
@@ -0,0 +1,226 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/msepp/myhours"
+	"github.com/msepp/myhours/database/databasetest"
+)
+
+func TestSQLite(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) myhours.Database {
+		handle, err := InitiateSQLiteDatabase(filepath.Join(t.TempDir(), "database.db"))
+		if err != nil {
+			t.Fatalf("InitiateSQLiteDatabase() error = %v", err)
+		}
+		t.Cleanup(func() { _ = handle.Close() })
+		return NewSQLite(handle)
+	})
+}
+
+// TestSQLite_pages runs the tests reading one record per page, to cover
+// iterating records over page boundaries.
+func TestSQLite_pages(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) myhours.Database {
+		handle, err := InitiateSQLiteDatabase(filepath.Join(t.TempDir(), "database.db"))
+		if err != nil {
+			t.Fatalf("InitiateSQLiteDatabase() error = %v", err)
+		}
+		t.Cleanup(func() { _ = handle.Close() })
+		return NewSQLite(handle, PageSize(1))
+	})
+}
+
+// TestSQLite_manyBreaks reads more records than fit in a single query for
+// breaks, to cover loading breaks in chunks.
+func TestSQLite_manyBreaks(t *testing.T) {
+	ctx := context.Background()
+	handle, err := InitiateSQLiteDatabase(filepath.Join(t.TempDir(), "database.db"))
+	if err != nil {
+		t.Fatalf("InitiateSQLiteDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { _ = handle.Close() })
+	var (
+		db      = NewSQLite(handle)
+		start   = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		records = make([]myhours.Record, 2*breaksChunkSize+1)
+	)
+	for i := range records {
+		at := start.Add(time.Duration(i) * time.Hour)
+		records[i] = myhours.Record{
+			Start:      at,
+			End:        at.Add(30 * time.Minute),
+			CategoryID: 1,
+			Breaks:     []myhours.Break{{Start: at.Add(10 * time.Minute), End: at.Add(20 * time.Minute)}},
+		}
+	}
+	if _, err = db.ImportRecords(ctx, records); err != nil {
+		t.Fatalf("ImportRecords() error = %v", err)
+	}
+	got, err := db.Records(ctx, start, start.Add(time.Duration(len(records))*time.Hour))
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	if len(got) != len(records) {
+		t.Fatalf("Records() returned %d records, want %d", len(got), len(records))
+	}
+	for _, record := range got {
+		if len(record.Breaks) != 1 || !record.Breaks[0].Start.Equal(record.Start.Add(10*time.Minute)) {
+			t.Fatalf("record %d breaks = %v, want its own break", record.ID, record.Breaks)
+		}
+	}
+}
+
+// FuzzSQLite_Aggregate compares the aggregates of SQLite to those of
+// myhours.AggregateRecords over the same records. Records are decoded from fuzz
+// data, six bytes each: days and minutes from the start of December of the
+// year, length in five minutes, and the end of a break in minutes from the
+// start. The break end also picks the number of pomodoros, and whether the
+// break is left open, or the record is shortened to end before the break
+// ends.
+func FuzzSQLite_Aggregate(f *testing.F) {
+	loc, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		f.Skipf("time zone not available: %v", err)
+	}
+	local := time.Local
+	time.Local = loc
+	f.Cleanup(func() { time.Local = local })
+	seeds := [][]uint16{
+		{62},       // 2026-02-01, month starts on Sunday
+		{118},      // 2026-03-29, clocks move forward
+		{395, 396}, // 2026-12-31 and 2027-01-01, ISO week 53 of 2026
+		{0, 31, 31, 59, 394, 397, 760},
+	}
+	for _, days := range seeds {
+		var data []byte
+		for i, d := range days {
+			// at 23:30, an hour long with a break of about ten minutes.
+			data = append(data, byte(d>>8), byte(d), byte(1410>>8), byte(1410&0xff), 12, byte(18+i))
+		}
+		f.Add(uint8(25), data)
+	}
+	f.Fuzz(func(t *testing.T, year uint8, data []byte) {
+		var (
+			ctx     = context.Background()
+			base    = time.Date(2000+int(year%50), 12, 1, 0, 0, 0, 0, time.Local)
+			records []myhours.Record
+		)
+		for i := 0; i+6 <= len(data) && len(records) < 100; i += 6 {
+			start := base.AddDate(0, 0, int(binary.BigEndian.Uint16(data[i:]))%800).
+				Add(time.Duration(binary.BigEndian.Uint16(data[i+2:])%1440) * time.Minute)
+			record := myhours.Record{
+				Start:      start,
+				End:        start.Add(time.Duration(data[i+4]) * 5 * time.Minute),
+				CategoryID: int64(data[i+4]%3 + 1),
+				Pomodoros:  int(data[i+5] % 3),
+			}
+			// the break may run past the end of the record.
+			b := time.Duration(data[i+5]) * time.Minute
+			record.Breaks = []myhours.Break{{Start: start.Add(b / 2), End: start.Add(b)}}
+			records = append(records, record)
+		}
+		handle, err := InitiateSQLiteDatabase(filepath.Join(t.TempDir(), "database.db"))
+		if err != nil {
+			t.Fatalf("InitiateSQLiteDatabase() error = %v", err)
+		}
+		defer func() { _ = handle.Close() }()
+		// the data is thrown away, no need to wait for it to reach the disk.
+		if _, err = handle.Exec("PRAGMA synchronous = OFF"); err != nil {
+			t.Fatalf("PRAGMA synchronous error = %v", err)
+		}
+		db := NewSQLite(handle)
+		ids, err := db.ImportRecords(ctx, records)
+		if err != nil {
+			t.Fatalf("ImportRecords() error = %v", err)
+		}
+		for i, id := range ids {
+			switch record := records[i]; record.Breaks[0].End.Minute() % 3 {
+			case 1:
+				// left open, as if the record was ended without ending breaks.
+				if _, err = handle.Exec(`UPDATE breaks SET "end" = NULL WHERE "record" = $1`, id); err != nil {
+					t.Fatalf("open break error = %v", err)
+				}
+			case 2:
+				// edited to end earlier, possibly before the break starts.
+				end := record.Start.Add(record.End.Sub(record.Start) / 3)
+				if err = db.UpdateRecord(ctx, id, record.CategoryID, record.Start, end, record.Notes); err != nil {
+					t.Fatalf("UpdateRecord() error = %v", err)
+				}
+			}
+		}
+		// the active record is left out of aggregates.
+		if _, err = db.StartRecord(ctx, base.AddDate(0, 0, 400), 1, ""); err != nil {
+			t.Fatalf("StartRecord() error = %v", err)
+		}
+		from, before := base.AddDate(0, 0, 17), base.AddDate(0, 0, 420)
+		stored, err := db.Records(ctx, from, before)
+		if err != nil {
+			t.Fatalf("Records() error = %v", err)
+		}
+		for _, period := range []myhours.Period{myhours.PeriodDay, myhours.PeriodWeek, myhours.PeriodMonth, myhours.PeriodYear, myhours.PeriodAll} {
+			for _, categoryID := range []int64{0, 2} {
+				for _, byCategory := range []bool{false, true} {
+					q := myhours.AggregateQuery{From: from, Before: before, CategoryID: categoryID, Period: period, ByCategory: byCategory}
+					got, err := db.Aggregate(ctx, q)
+					if err != nil {
+						t.Fatalf("Aggregate() error = %v", err)
+					}
+					want := myhours.AggregateRecords(stored, q)
+					if !slices.EqualFunc(got, want, func(a, b myhours.Aggregate) bool {
+						return a.Start.Equal(b.Start) && a.CategoryID == b.CategoryID && a.Records == b.Records &&
+							a.Duration == b.Duration && a.Breaks == b.Breaks && a.Pomodoros == b.Pomodoros
+					}) {
+						t.Errorf("Aggregate(%+v) = %v, want %v", q, got, want)
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestSQLite_transient(t *testing.T) {
+	var (
+		ctx  = context.Background()
+		path = filepath.Join(t.TempDir(), "database.db")
+	)
+	handle, err := InitiateSQLiteDatabase(path)
+	if err != nil {
+		t.Fatalf("InitiateSQLiteDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { _ = handle.Close() })
+	// fail right away when locked, instead of waiting for the lock.
+	handle.SetMaxOpenConns(1)
+	if _, err = handle.Exec("PRAGMA busy_timeout = 0"); err != nil {
+		t.Fatalf("PRAGMA busy_timeout error = %v", err)
+	}
+	db := NewSQLite(handle)
+	// errors of the data are permanent.
+	if err = db.ResumeRecord(ctx, 1, time.Now()); err == nil || errors.Is(err, myhours.ErrTransient) {
+		t.Errorf("ResumeRecord() error = %v, want permanent error", err)
+	}
+	// the database being locked by someone else is transient.
+	other, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open() error = %v", err)
+	}
+	t.Cleanup(func() { _ = other.Close() })
+	conn, err := other.Conn(ctx)
+	if err != nil {
+		t.Fatalf("other.Conn() error = %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	if _, err = conn.ExecContext(ctx, "BEGIN EXCLUSIVE"); err != nil {
+		t.Fatalf("BEGIN EXCLUSIVE error = %v", err)
+	}
+	if _, err = db.StartRecord(ctx, time.Now(), 1, ""); !errors.Is(err, myhours.ErrTransient) {
+		t.Errorf("StartRecord() error = %v, want transient error", err)
+	}
+}
@@ -0,0 +1,25 @@
+-- breaks taken within records.
+CREATE TABLE IF NOT EXISTS breaks (
+    id     INTEGER PRIMARY KEY,
+    record INTEGER     NOT NULL REFERENCES records (id) ON DELETE CASCADE,
+    start  VARCHAR(35) NOT NULL,
+    end    VARCHAR(35)
+);
+CREATE INDEX IF NOT EXISTS breaks_record ON breaks (record);
+
+-- pomodoros completed during records.
+CREATE TABLE IF NOT EXISTS pomodoros (
+    id        INTEGER PRIMARY KEY,
+    record    INTEGER     NOT NULL REFERENCES records (id) ON DELETE CASCADE,
+    completed VARCHAR(35) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS pomodoros_record ON pomodoros (record);
+
+-- configuration values added after the initial schema.
+INSERT INTO configuration
+(key, value)
+VALUES
+    ('forgotten_timer_threshold', '10h'),
+    ('report_show_breaks', 'false'),
+    ('pinned_tasks', '[]')
+ON CONFLICT DO NOTHING;
@@ -17,6 +17,23 @@ CREATE TABLE IF NOT EXISTS records (
     notes    TEXT
 );
 
+-- breaks taken within records.
+CREATE TABLE IF NOT EXISTS breaks (
+    id     INTEGER PRIMARY KEY,
+    record INTEGER     NOT NULL REFERENCES records (id) ON DELETE CASCADE,
+    start  VARCHAR(35) NOT NULL,
+    end    VARCHAR(35)
+);
+CREATE INDEX IF NOT EXISTS breaks_record ON breaks (record);
+
+-- pomodoros completed during records.
+CREATE TABLE IF NOT EXISTS pomodoros (
+    id        INTEGER PRIMARY KEY,
+    record    INTEGER     NOT NULL REFERENCES records (id) ON DELETE CASCADE,
+    completed VARCHAR(35) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS pomodoros_record ON pomodoros (record);
+
 -- global configuration, things like default category, default view.
 CREATE TABLE IF NOT EXISTS configuration (
     key   VARCHAR(50) PRIMARY KEY,
@@ -39,4 +56,7 @@ ON CONFLICT DO UPDATE SET color_light_fg = excluded.color_light_fg,
 INSERT INTO configuration
 (key, value)
 VALUES
-    ('default_category', '3');
\ No newline at end of file
+    ('default_category', '3'),
+    ('forgotten_timer_threshold', '10h'),
+    ('report_show_breaks', 'false'),
+    ('pinned_tasks', '[]');
\ No newline at end of file
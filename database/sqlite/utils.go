@@ -1,12 +1,35 @@
 package sqlite
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
+	driver "github.com/glebarez/go-sqlite"
 	"github.com/msepp/myhours"
 )
 
+// Primary result codes of SQLite errors that may go away when retried.
+const (
+	codeBusy   = 5  // SQLITE_BUSY
+	codeLocked = 6  // SQLITE_LOCKED
+	codeIOErr  = 10 // SQLITE_IOERR
+	codeFull   = 13 // SQLITE_FULL
+)
+
+// markTransient wraps *err with myhours.ErrTransient, if it is caused by the
+// database being busy or locked, or by failing I/O.
+func markTransient(err *error) {
+	var sqliteErr *driver.Error
+	if *err == nil || !errors.As(*err, &sqliteErr) {
+		return
+	}
+	switch sqliteErr.Code() & 0xff {
+	case codeBusy, codeLocked, codeIOErr, codeFull:
+		*err = fmt.Errorf("%w: %w", myhours.ErrTransient, *err)
+	}
+}
+
 // val returns the value of any pointer, or the zero value of the type if pointer
 // is nil.
 func val[T any](p *T) T {
@@ -35,11 +58,12 @@ func scanRecord(row scanner) (*myhours.Record, error) {
 		start      string
 		end, notes *string
 		categoryID int64
+		pomodoros  int
 	)
-	if err := row.Scan(&id, &start, &end, &categoryID, &notes); err != nil {
+	if err := row.Scan(&id, &start, &end, &categoryID, &notes, &pomodoros); err != nil {
 		return nil, fmt.Errorf("row.Scan: %w", err)
 	}
-	record := myhours.Record{ID: id, CategoryID: categoryID, Notes: val(notes)}
+	record := myhours.Record{ID: id, CategoryID: categoryID, Notes: val(notes), Pomodoros: pomodoros}
 	var err error
 	if record.Start, err = time.Parse(time.RFC3339Nano, start); err != nil {
 		return nil, fmt.Errorf("time.Parse(start): %w", err)
@@ -53,3 +77,36 @@ func scanRecord(row scanner) (*myhours.Record, error) {
 	}
 	return &record, nil
 }
+
+// pointers returns pointers to the elements of given slice.
+func pointers[T any](s []T) []*T {
+	ptrs := make([]*T, len(s))
+	for i := range s {
+		ptrs[i] = &s[i]
+	}
+	return ptrs
+}
+
+func scanBreak(row scanner) (int64, myhours.Break, error) {
+	var (
+		recordID int64
+		start    string
+		end      *string
+		b        myhours.Break
+		err      error
+	)
+	if err = row.Scan(&recordID, &start, &end); err != nil {
+		return 0, b, fmt.Errorf("row.Scan: %w", err)
+	}
+	if b.Start, err = time.Parse(time.RFC3339Nano, start); err != nil {
+		return 0, b, fmt.Errorf("time.Parse(start): %w", err)
+	}
+	b.Start = b.Start.In(time.Local)
+	if end != nil {
+		if b.End, err = time.Parse(time.RFC3339Nano, *end); err != nil {
+			return 0, b, fmt.Errorf("time.Parse(end): %w", err)
+		}
+		b.End = b.End.In(time.Local)
+	}
+	return recordID, b, nil
+}
@@ -0,0 +1,34 @@
+package myhours_test
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/msepp/myhours"
+	"github.com/msepp/myhours/database/databasetest"
+	"github.com/msepp/myhours/database/memory"
+)
+
+// TestDecorators runs the conformance suite over each decorator wrapped
+// around the memory backend.
+func TestDecorators(t *testing.T) {
+	tests := []struct {
+		name     string
+		decorate func(myhours.Database) myhours.Database
+	}{
+		{name: "logging", decorate: func(db myhours.Database) myhours.Database {
+			return myhours.LoggingDatabase(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		}},
+		{name: "timing", decorate: func(db myhours.Database) myhours.Database {
+			return myhours.TimingDatabase(db, func(string, time.Duration) {})
+		}},
+		{name: "caching", decorate: myhours.CachingDatabase},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			databasetest.Run(t, func(*testing.T) myhours.Database { return tt.decorate(memory.NewMemory()) })
+		})
+	}
+}
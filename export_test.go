@@ -0,0 +1,61 @@
+package myhours
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Report gives the tests of package myhours_test access to a report.
+type Report struct{ r report }
+
+var (
+	ReportWeekly  = Report{reportWeekly}
+	ReportMonthly = Report{reportMonthly}
+	ReportYearly  = Report{reportYearly}
+)
+
+// FuzzRecordSets runs check for records decoded from fuzz data.
+var FuzzRecordSets = fuzzRecordSets
+
+// Dates returns the range of the report page shown as of now.
+func (r Report) Dates(now time.Time, pageNo int) (time.Time, time.Time) {
+	return r.r.dates(now, pageNo)
+}
+
+// Rows builds the rows of the report from the records of db in [from, before).
+func (r Report) Rows(ctx context.Context, db RecordStore, from, before time.Time, categoryID int64, showBreaks bool) ([][]string, error) {
+	return r.r.rows(ctx, db, from, before, categoryID, reportOptions{showBreaks: showBreaks})
+}
+
+// FakeClock is a clock that only moves when told to. Safe for concurrent use.
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock returns a clock stopped at given time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the time the clock is at.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Set the clock to given time.
+func (c *FakeClock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// Advance the clock by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
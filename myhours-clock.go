@@ -0,0 +1,56 @@
+package myhours
+
+import "time"
+
+// Clock tells the current time. The application reads the time only through a
+// clock, so that it can be run at a fixed time, for example in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock is the clock of the system, used by default.
+type SystemClock struct{}
+
+// Now returns the current local time.
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// FixedClock is a clock stopped at the time it's set to.
+type FixedClock time.Time
+
+// Now returns the time the clock is stopped at.
+func (c FixedClock) Now() time.Time { return time.Time(c) }
+
+// UseClock sets the clock the application reads the time from. If nil, the
+// system clock is used.
+func UseClock(c Clock) Option {
+	return func(app *MyHours) {
+		if c == nil {
+			c = SystemClock{}
+		}
+		app.clock = c
+		app.timer.clock = c
+	}
+}
+
+// UseReportClock sets the clock reports are shown as of: the latest report
+// page is the one containing its time. Use FixedClock to show reports as of a
+// date. Timer and totals still follow the application clock. If nil, reports
+// follow the application clock as well.
+func UseReportClock(c Clock) Option {
+	return func(app *MyHours) {
+		app.reportClock = c
+	}
+}
+
+// now returns the current time from the application clock.
+func (m MyHours) now() time.Time {
+	return m.clock.Now()
+}
+
+// reportNow returns the time reports are shown as of.
+func (m MyHours) reportNow() time.Time {
+	if m.reportClock != nil {
+		return m.reportClock.Now()
+	}
+	return m.now()
+}
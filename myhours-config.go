@@ -1,8 +1,253 @@
 package myhours
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Setting identifies a value in application configuration settings.
+type Setting string
+
+func (s Setting) String() string { return string(s) }
+
+const (
+	// SettingDefaultCategory is the setting key for default category.
+	SettingDefaultCategory Setting = "default_category"
+	// SettingForgottenTimerThreshold is the setting key for the duration after
+	// which a running timer is suspected to be forgotten.
+	SettingForgottenTimerThreshold Setting = "forgotten_timer_threshold"
+	// SettingReportShowBreaks is the setting key for showing break time as its
+	// own column in reports.
+	SettingReportShowBreaks Setting = "report_show_breaks"
+	// SettingPinnedTasks is the setting key for favourite tasks. Value is a
+	// JSON array of tasks.
+	SettingPinnedTasks Setting = "pinned_tasks"
+)
+
 // Settings contains the global configuration values for the application.
 type Settings struct {
 	// DefaultCategoryID is the category that is set by default work any recorded
 	// time.
 	DefaultCategoryID int64
+	// ForgottenTimerThreshold is the time after which a running timer is suspected
+	// to be forgotten. Zero disables the check, except for timers that have been
+	// running over night.
+	ForgottenTimerThreshold time.Duration
+	// ReportShowBreaks shows time spent on breaks as its own column in reports.
+	ReportShowBreaks bool
+	// PinnedTasks are favourite tasks, always listed before recent tasks.
+	PinnedTasks []Task
+	// Unknown contains the stored settings that are not supported by this
+	// version of the application, by key. These are listed in the settings
+	// view, but otherwise ignored.
+	Unknown map[string]string
+}
+
+// SettingSpec describes a setting: how its stored value is parsed into
+// Settings and formatted back, and what values are valid. Values are stored
+// as text.
+type SettingSpec struct {
+	// Key identifies the setting.
+	Key Setting
+	// Type of the value, shown to users. One of category, duration, bool or
+	// json.
+	Type string
+	// Description of the setting, shown to users.
+	Description string
+	// Default is the value used when the setting has not been stored.
+	Default string
+	// Parse parses the value and sets it to settings.
+	Parse func(value string, s *Settings) error
+	// Format returns the value of the setting from settings, in stored form.
+	Format func(s Settings) string
+	// Validate checks the value of the setting in settings, if needed. Checks
+	// against categories are skipped if categories is nil.
+	Validate func(s Settings, categories []Category) error
+}
+
+// settingSpecs are the known settings. New settings are added here, and to
+// the Settings struct.
+var settingSpecs = []SettingSpec{
+	{
+		Key:         SettingDefaultCategory,
+		Type:        "category",
+		Description: "Category of new records, and the category shown in reports.",
+		// matches the category set up in default databases.
+		Default: "3",
+		Parse: func(value string, s *Settings) (err error) {
+			s.DefaultCategoryID, err = strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return fmt.Errorf("strconv.ParseInt: %w", err)
+			}
+			return nil
+		},
+		Format: func(s Settings) string {
+			return strconv.FormatInt(s.DefaultCategoryID, 10)
+		},
+		Validate: func(s Settings, categories []Category) error {
+			if categories != nil && findCategory(categories, s.DefaultCategoryID).ID == 0 {
+				return fmt.Errorf("unknown category %d", s.DefaultCategoryID)
+			}
+			return nil
+		},
+	},
+	{
+		Key:         SettingForgottenTimerThreshold,
+		Type:        "duration",
+		Description: "Running time after which the timer is suspected to be forgotten. Zero checks only for timers running over night.",
+		Default:     "10h",
+		Parse: func(value string, s *Settings) (err error) {
+			s.ForgottenTimerThreshold, err = time.ParseDuration(value)
+			if err != nil {
+				return fmt.Errorf("time.ParseDuration: %w", err)
+			}
+			return nil
+		},
+		Format: func(s Settings) string {
+			return s.ForgottenTimerThreshold.String()
+		},
+		Validate: func(s Settings, _ []Category) error {
+			if s.ForgottenTimerThreshold < 0 {
+				return errors.New("duration can not be negative")
+			}
+			return nil
+		},
+	},
+	{
+		Key:         SettingReportShowBreaks,
+		Type:        "bool",
+		Description: "Show time spent on breaks as its own column in reports.",
+		Default:     "false",
+		Parse: func(value string, s *Settings) (err error) {
+			s.ReportShowBreaks, err = strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("strconv.ParseBool: %w", err)
+			}
+			return nil
+		},
+		Format: func(s Settings) string {
+			return strconv.FormatBool(s.ReportShowBreaks)
+		},
+	},
+	{
+		Key:         SettingPinnedTasks,
+		Type:        "json",
+		Description: `Tasks listed before recent tasks, like [{"category":1,"notes":"standup"}].`,
+		Default:     "[]",
+		Parse: func(value string, s *Settings) error {
+			var tasks []Task
+			if err := json.Unmarshal([]byte(value), &tasks); err != nil {
+				return fmt.Errorf("json.Unmarshal: %w", err)
+			}
+			s.PinnedTasks = tasks
+			return nil
+		},
+		Format: func(s Settings) string {
+			value, _ := json.Marshal(emptyIfNil(s.PinnedTasks))
+			return string(value)
+		},
+		Validate: func(s Settings, categories []Category) error {
+			for _, task := range s.PinnedTasks {
+				if categories != nil && findCategory(categories, task.CategoryID).ID == 0 {
+					return fmt.Errorf("unknown category %d", task.CategoryID)
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// SettingSpecs returns the specs of all known settings.
+func SettingSpecs() []SettingSpec {
+	return slices.Clone(settingSpecs)
+}
+
+// LookupSetting returns the spec of the setting identified by key. Returns
+// false if the setting is not known.
+func LookupSetting(key Setting) (SettingSpec, bool) {
+	for _, spec := range settingSpecs {
+		if spec.Key == key {
+			return spec, true
+		}
+	}
+	return SettingSpec{}, false
+}
+
+// DefaultSettings returns the settings used when nothing has been configured.
+func DefaultSettings() Settings {
+	var s Settings
+	for _, spec := range settingSpecs {
+		// defaults are covered by tests, they always parse.
+		_ = spec.Parse(spec.Default, &s)
+	}
+	return s
+}
+
+// ParseSettings returns the settings from stored values by key. Settings
+// that are not stored have their default value. Unknown keys are collected
+// into Settings.Unknown.
+//
+// Returns an error if any known setting has an invalid value.
+func ParseSettings(values map[string]string) (Settings, error) {
+	s := DefaultSettings()
+	var errs []error
+	for key, value := range values {
+		spec, ok := LookupSetting(Setting(key))
+		if !ok {
+			if s.Unknown == nil {
+				s.Unknown = make(map[string]string)
+			}
+			s.Unknown[key] = value
+			continue
+		}
+		if err := spec.Parse(value, &s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+	// sort for stable error messages, map iteration order is random.
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return s, errors.Join(errs...)
+}
+
+// ValidateSetting checks that value is valid for the setting identified by
+// key, without checks against other data such as categories. Used by database
+// implementations before storing a value.
+func ValidateSetting(key Setting, value string) error {
+	_, _, err := DefaultSettings().Set(key, value, nil)
+	return err
+}
+
+// Set parses and validates value for the setting identified by key. Values
+// referring to categories are checked against given categories, unless nil.
+//
+// Returns a copy of s with the value set, and the value in the form it should
+// be stored in.
+func (s Settings) Set(key Setting, value string, categories []Category) (Settings, string, error) {
+	spec, ok := LookupSetting(key)
+	if !ok {
+		return s, "", fmt.Errorf("unknown setting %q", key)
+	}
+	if err := spec.Parse(value, &s); err != nil {
+		return s, "", fmt.Errorf("invalid %s %q: %w", spec.Type, value, err)
+	}
+	if spec.Validate != nil {
+		if err := spec.Validate(s, categories); err != nil {
+			return s, "", fmt.Errorf("invalid %s %q: %w", spec.Type, value, err)
+		}
+	}
+	return s, spec.Format(s), nil
+}
+
+// emptyIfNil returns the slice, or an empty slice if nil. Used for encoding
+// lists as JSON arrays rather than null.
+func emptyIfNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
 }
@@ -0,0 +1,79 @@
+package myhours
+
+import (
+	"fmt"
+	"log/slog"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Minimum terminal size for the dashboard layout. On smaller terminals the
+// timer view shows only the timer.
+const (
+	dashboardMinWidth  = 140
+	dashboardMinHeight = 30
+	// dashboardTimerWidth is the width of the timer column in dashboard.
+	dashboardTimerWidth = 46
+)
+
+// dashboard is shown in place of the timer view on wide terminals. Next to the
+// timer, it shows the records of today and the report for this week.
+type dashboard struct {
+	enabled bool
+	// loaded is set once reports have been loaded.
+	loaded bool
+	day    reportTable
+	week   reportTable
+}
+
+// useDashboard returns true if a terminal of given size fits the dashboard.
+func useDashboard(width, height int) bool {
+	return width >= dashboardMinWidth && height >= dashboardMinHeight
+}
+
+// loadDashboard returns a command that loads the reports of the dashboard. If
+// the dashboard is not in use, nil is returned.
+func (m MyHours) loadDashboard() tea.Cmd {
+	if !m.state.dashboard.enabled {
+		return nil
+	}
+	var (
+		categoryID = m.settings.DefaultCategoryID
+		opts       = reportOptions{showBreaks: m.settings.ReportShowBreaks}
+		now        = m.reportNow()
+	)
+	return func() tea.Msg {
+		msg := dashboardDataMsg{categoryID: categoryID}
+		var err error
+		if msg.day, err = reportDaily.build(m.ctx, m.db, now, 0, categoryID, opts); err != nil {
+			m.l.Error("failed to fetch records", slog.String("error", err.Error()))
+			msg.err = fmt.Errorf("loading dashboard failed: %w", err)
+			return msg
+		}
+		if msg.week, err = reportWeekly.build(m.ctx, m.db, now, 0, categoryID, opts); err != nil {
+			m.l.Error("failed to fetch records", slog.String("error", err.Error()))
+			msg.err = fmt.Errorf("loading dashboard failed: %w", err)
+		}
+		return msg
+	}
+}
+
+// renderDashboard renders the timer, records of today and the weekly report
+// side by side.
+func (m MyHours) renderDashboard(width, height int) string {
+	if !m.state.dashboard.loaded {
+		return m.renderTimer(width, height)
+	}
+	timer := m.renderTimer(dashboardTimerWidth, height)
+	// reports share the space left over from the timer. Daily report has more
+	// columns, so it gets a larger share.
+	rest := width - lipgloss.Width(timer)
+	dayWidth := rest * 3 / 5
+	weekWidth := rest - dayWidth
+	// tables have only a title above them.
+	tableHeight := height - styleReportContainer.GetVerticalFrameSize() - 1
+	day := styleReportContainer.Width(dayWidth).Render(m.renderReportTable(dayWidth, tableHeight, m.state.dashboard.day, nil))
+	week := styleReportContainer.Width(weekWidth).Render(m.renderReportTable(weekWidth, tableHeight, m.state.dashboard.week, nil))
+	return lipgloss.JoinHorizontal(lipgloss.Top, timer, day, week)
+}
@@ -16,7 +16,7 @@ import (
 func (m MyHours) Init() tea.Cmd {
 	commands := []tea.Cmd{
 		func() tea.Msg {
-			categories, err := m.db.Categories()
+			categories, err := m.db.Categories(m.ctx)
 			if err != nil {
 				err = fmt.Errorf("db.Categories: %w", err)
 				m.l.Error("application init failed", slog.String("error", err.Error()))
@@ -25,7 +25,7 @@ func (m MyHours) Init() tea.Cmd {
 			return updateCategoriesMsg{categories: categories}
 		},
 		func() tea.Msg {
-			settings, err := m.db.Settings()
+			settings, err := m.db.Settings(m.ctx)
 			if err != nil {
 				err = fmt.Errorf("db.Settings: %w", err)
 				m.l.Error("application init failed", slog.String("error", err.Error()))
@@ -34,7 +34,7 @@ func (m MyHours) Init() tea.Cmd {
 			return updateSettingsMsg{settings: *settings}
 		},
 		func() tea.Msg {
-			record, err := m.db.ActiveRecord()
+			record, err := m.db.ActiveRecord(m.ctx)
 			if err != nil {
 				err = fmt.Errorf("db.ActiveRecord: %w", err)
 				m.l.Error("application init failed", slog.String("error", err.Error()))
@@ -46,6 +46,7 @@ func (m MyHours) Init() tea.Cmd {
 			}
 			return initTimerMsg{record: *record}
 		},
+		m.loadRecentTasks(),
 	}
 	return tea.Sequence(commands...)
 }
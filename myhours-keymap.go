@@ -1,10 +1,41 @@
 package myhours
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// KeyBindings maps action names to the keys that trigger them. Used to override
+// the default key bindings of the application, for example from a user
+// configuration file. Actions that are not present keep their default keys.
+//
+// See KeyActions for the list of supported action names.
+type KeyBindings map[string][]string
+
+// Validate checks that the key bindings refer to known actions only, and that
+// the resulting key map has no conflicting bindings.
+func (b KeyBindings) Validate() error {
+	keys := newKeymap()
+	return keys.apply(b)
+}
+
+// KeyActions returns the names of all actions that can be rebound with
+// KeyBindings, in alphabetical order.
+func KeyActions() []string {
+	keys := newKeymap()
+	var names []string
+	for name := range keys.actions() {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
 type keymap struct {
 	switchGlobalCategory key.Binding
 	switchTaskCategory   key.Binding
@@ -12,9 +43,24 @@ type keymap struct {
 	prevTab              key.Binding
 	prevReportPage       key.Binding
 	nextReportPage       key.Binding
+	reportRowUp          key.Binding
+	reportRowDown        key.Binding
+	sortReport           key.Binding
+	reverseSort          key.Binding
 	startRecord          key.Binding
 	stopRecord           key.Binding
+	startRecordAt        key.Binding
+	stopRecordAt         key.Binding
 	newRecord            key.Binding
+	switchRecord         key.Binding
+	pauseRecord          key.Binding
+	resumeRecord         key.Binding
+	toggleBreaks         key.Binding
+	timebox              key.Binding
+	startTask            key.Binding
+	pinTask              key.Binding
+	palette              key.Binding
+	editSetting          key.Binding
 	openHelp             key.Binding
 	closeHelp            key.Binding
 	quit                 key.Binding
@@ -55,6 +101,22 @@ func newKeymap() keymap {
 			key.WithKeys("up", "k"),
 			key.WithHelp("k, ↑", "Back in time"),
 		),
+		reportRowUp: key.NewBinding(
+			key.WithKeys("K", "pgup"),
+			key.WithHelp("K", "Row up"),
+		),
+		reportRowDown: key.NewBinding(
+			key.WithKeys("J", "pgdown"),
+			key.WithHelp("J", "Row down"),
+		),
+		sortReport: key.NewBinding(
+			key.WithKeys("."),
+			key.WithHelp(".", "Sort by next column"),
+		),
+		reverseSort: key.NewBinding(
+			key.WithKeys("r"),
+			key.WithHelp("r", "Reverse sort"),
+		),
 		startRecord: key.NewBinding(
 			key.WithKeys("s"),
 			key.WithHelp("s", "Start"),
@@ -63,10 +125,54 @@ func newKeymap() keymap {
 			key.WithKeys("s"),
 			key.WithHelp("s", "Stop"),
 		),
+		startRecordAt: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "Start at..."),
+		),
+		stopRecordAt: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "Stop at..."),
+		),
 		newRecord: key.NewBinding(
 			key.WithKeys("n"),
 			key.WithHelp("n", "New"),
 		),
+		switchRecord: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "Switch task"),
+		),
+		pauseRecord: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "Pause"),
+		),
+		resumeRecord: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "Resume"),
+		),
+		toggleBreaks: key.NewBinding(
+			key.WithKeys("b"),
+			key.WithHelp("b", "Toggle breaks column"),
+		),
+		timebox: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "Timebox"),
+		),
+		startTask: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1-9", "Start listed task"),
+		),
+		pinTask: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "Pin/unpin task"),
+		),
+		editSetting: key.NewBinding(
+			key.WithKeys("e", "enter"),
+			key.WithHelp("e", "Edit setting"),
+		),
+		palette: key.NewBinding(
+			key.WithKeys(":"),
+			key.WithHelp(":", "Commands"),
+		),
 		switchTaskCategory: key.NewBinding(
 			key.WithKeys("t"),
 			key.WithHelp("t", "Switch task category"),
@@ -77,3 +183,151 @@ func newKeymap() keymap {
 		),
 	}
 }
+
+// actions returns the configurable bindings of the key map by action name.
+// The pointers refer to the bindings of this key map.
+func (k *keymap) actions() map[string]*key.Binding {
+	return map[string]*key.Binding{
+		"fullscreen":           &k.fullScreen,
+		"help":                 &k.openHelp,
+		"close_help":           &k.closeHelp,
+		"switch_category":      &k.switchGlobalCategory,
+		"switch_task_category": &k.switchTaskCategory,
+		"next_view":            &k.nextTab,
+		"prev_view":            &k.prevTab,
+		"next_page":            &k.nextReportPage,
+		"prev_page":            &k.prevReportPage,
+		"row_up":               &k.reportRowUp,
+		"row_down":             &k.reportRowDown,
+		"sort":                 &k.sortReport,
+		"reverse_sort":         &k.reverseSort,
+		"start":                &k.startRecord,
+		"stop":                 &k.stopRecord,
+		"start_at":             &k.startRecordAt,
+		"stop_at":              &k.stopRecordAt,
+		"new":                  &k.newRecord,
+		"switch":               &k.switchRecord,
+		"pause":                &k.pauseRecord,
+		"resume":               &k.resumeRecord,
+		"toggle_breaks":        &k.toggleBreaks,
+		"timebox":              &k.timebox,
+		"start_task":           &k.startTask,
+		"pin_task":             &k.pinTask,
+		"palette":              &k.palette,
+		"edit_setting":         &k.editSetting,
+		"quit":                 &k.quit,
+	}
+}
+
+// sharedKeys lists the action pairs that are allowed to share keys. These are
+// toggles where only one of the pair is enabled at any given time.
+var sharedKeys = [][2]string{
+	{"start", "stop"},
+	{"start_at", "stop_at"},
+	{"help", "close_help"},
+	{"pause", "resume"},
+}
+
+// apply the given overrides to the key map. Help texts of the overridden
+// bindings are updated to show the configured keys.
+//
+// Returns an error if an override targets an unknown action, has no keys, or
+// if the key map has conflicting bindings after the overrides are applied.
+func (k *keymap) apply(overrides KeyBindings) error {
+	actions := k.actions()
+	for name, keys := range overrides {
+		binding, ok := actions[name]
+		if !ok {
+			return fmt.Errorf("unknown key action %q", name)
+		}
+		if len(keys) == 0 || slices.Contains(keys, "") {
+			return fmt.Errorf("key action %q: keys must be non-empty", name)
+		}
+		binding.SetKeys(keys...)
+		binding.SetHelp(helpKeys(keys), binding.Help().Desc)
+	}
+	return k.conflicts()
+}
+
+// conflicts returns an error describing all bindings that share a key, except
+// for the pairs listed in sharedKeys.
+func (k *keymap) conflicts() error {
+	actions := k.actions()
+	owners := make(map[string][]string)
+	for name, binding := range actions {
+		for _, kk := range binding.Keys() {
+			owners[kk] = append(owners[kk], name)
+		}
+	}
+	var errs []error
+	for kk, names := range owners {
+		slices.Sort(names)
+		for i := range names {
+			for _, other := range names[i+1:] {
+				if !mayShareKeys(names[i], other) {
+					errs = append(errs, fmt.Errorf("key %q is bound to both %q and %q", kk, names[i], other))
+				}
+			}
+		}
+	}
+	// sort for stable error messages, map iteration order is random.
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
+
+// mayShareKeys returns true if actions a and b are allowed to share keys.
+func mayShareKeys(a, b string) bool {
+	for _, pair := range sharedKeys {
+		if (pair[0] == a && pair[1] == b) || (pair[0] == b && pair[1] == a) {
+			return true
+		}
+	}
+	return false
+}
+
+// keyTypes maps key names to the types of keys that have a name, like "esc".
+var keyTypes = func() map[string]tea.KeyType {
+	types := make(map[string]tea.KeyType)
+	for t := tea.KeyType(-256); t < 256; t++ {
+		if name := t.String(); name != "" {
+			types[name] = t
+		}
+	}
+	return types
+}()
+
+// keyMsg returns the key press message for a key as used in bindings, such
+// that the message matches bindings with the key.
+func keyMsg(k string) tea.KeyMsg {
+	var alt bool
+	if rest, ok := strings.CutPrefix(k, "alt+"); ok && rest != "" {
+		k, alt = rest, true
+	}
+	if t, ok := keyTypes[k]; ok {
+		return tea.KeyMsg{Type: t, Alt: alt}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k), Alt: alt}
+}
+
+// helpKeys formats the given keys for help texts. Arrow keys are shown as
+// arrows.
+func helpKeys(keys []string) string {
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		switch k {
+		case "left":
+			labels[i] = "←"
+		case "right":
+			labels[i] = "→"
+		case "up":
+			labels[i] = "↑"
+		case "down":
+			labels[i] = "↓"
+		case " ":
+			labels[i] = "space"
+		default:
+			labels[i] = k
+		}
+	}
+	return strings.Join(labels, ", ")
+}
@@ -17,6 +17,62 @@ type updateRecordMsg struct {
 	record Record
 }
 
+// writeFailedMsg is sent when storing a record failed. The record is queued
+// for writing later if the error is transient.
+type writeFailedMsg struct {
+	record Record
+	err    error
+}
+
+// retryWritesMsg is sent when pending record writes should be retried.
+type retryWritesMsg struct{}
+
+// pendingWritesMsg contains the results of writing pending records and events.
+type pendingWritesMsg struct {
+	// stored contains the records that were written. Records that were inserted
+	// have their IDs set.
+	stored []Record
+	// failed contains the records that could not be written, again.
+	failed []Record
+	// failedEvents contains the events that could not be written, in order.
+	failedEvents []recordEvent
+	// err is the error of the last write to retry.
+	err error
+	// dropped contains the records given up on, retrying wouldn't help.
+	dropped []Record
+	// droppedErr is the error of the last write given up on.
+	droppedErr error
+}
+
+// settingsWrittenMsg contains the results of writing setting values.
+type settingsWrittenMsg struct {
+	// stored, failed and dropped contain the values that were written, that
+	// could not be written, and that were given up on.
+	stored, failed, dropped []settingWrite
+	// err is the error of the last write to retry.
+	err error
+	// droppedErr is the error of the last write given up on.
+	droppedErr error
+	// settings are the settings with the values applied, if any.
+	settings *Settings
+}
+
+// errorMsg is sent when an error should be shown to the user.
+type errorMsg struct {
+	err error
+}
+
+// statusMsg is sent to show a notification to the user.
+type statusMsg struct {
+	text string
+}
+
+// clearStatusMsg clears the status notification, if it's still the one
+// identified by id.
+type clearStatusMsg struct {
+	id int
+}
+
 // initTimerMsg is sent to select the initial active task
 type initTimerMsg struct {
 	record Record
@@ -24,16 +80,23 @@ type initTimerMsg struct {
 
 // reportDataMessage contains data for reporting table.
 type reportDataMsg struct {
-	// viewID identifies the target report view. If current state view
-	// isn't matching, then someone may have cycled the views very quickly and
-	// the data in this message isn't needed anymore.
+	// viewID identifies the target report view. Loads are cancelled when the
+	// view changes, but if current state view isn't matching, the load
+	// completed before it was cancelled and the data isn't needed anymore.
 	viewID     int
 	pageNo     int
 	categoryID int64
-	title      string
-	headers    []string
-	rows       [][]string
-	style      reportStyleFunc
+	// from, opts and generation are needed for caching the page.
+	from       time.Time
+	opts       reportOptions
+	generation int
+	// prefetch is set for pages loaded for the cache only.
+	prefetch bool
+	title    string
+	headers  []string
+	rows     [][]string
+	style    reportStyleFunc
+	err      error
 }
 
 // timerTickMsg is a message that is sent on every timer timerTick.
@@ -44,6 +107,20 @@ type timerTickMsg struct {
 // timerStartMsg is sent when the stopwatch should start.
 type timerStartMsg struct {
 	from time.Time
+	// breaks is the total time of finished breaks since from.
+	breaks time.Duration
+	// pausedAt is the start of a break that is on, if any.
+	pausedAt time.Time
+}
+
+// timerPauseMsg is sent when a break starts.
+type timerPauseMsg struct {
+	at time.Time
+}
+
+// timerResumeMsg is sent when a break ends.
+type timerResumeMsg struct {
+	at time.Time
 }
 
 // timerStopMsg is sent when the stopwatch should stop.
@@ -52,5 +129,71 @@ type timerStopMsg struct {
 	end   time.Time
 }
 
+// timerJumpMsg is sent when the wall clock has jumped between timer ticks.
+type timerJumpMsg struct {
+	from time.Time
+	to   time.Time
+}
+
+// recordSwitchedMsg is sent when the active record was ended and a new record
+// was started to continue from it.
+type recordSwitchedMsg struct {
+	ended   Record
+	started Record
+}
+
+// lastRecordMsg contains the last finished record, if any, or the error
+// loading it.
+type lastRecordMsg struct {
+	record Record
+	err    error
+}
+
+// recentTasksMsg contains the latest distinct tasks from records.
+type recentTasksMsg struct {
+	tasks []Task
+}
+
+// totalsMsg contains the records for totals of given day and week.
+type totalsMsg struct {
+	day     time.Time
+	week    time.Time
+	records []Record
+	err     error
+}
+
+// dashboardDataMsg contains the report data for the dashboard.
+type dashboardDataMsg struct {
+	categoryID int64
+	day        reportTable
+	week       reportTable
+	err        error
+}
+
 // timerResetMsg resets the timer
 type timerResetMsg struct{}
+
+// actionMsg runs a key action as if its key was pressed. Sent from the command
+// palette.
+type actionMsg struct {
+	action string
+	// key is the key to press, for actions with several keys. Defaults to the
+	// first key of the action.
+	key string
+}
+
+// gotoReportMsg switches to a report view, on given page.
+type gotoReportMsg struct {
+	viewID int
+	pageNo int
+}
+
+// exportReportMsg requests exporting the report in view to a CSV file.
+type exportReportMsg struct {
+	path string
+}
+
+// setNotesMsg sets the notes of the active record.
+type setNotesMsg struct {
+	notes string
+}
@@ -0,0 +1,325 @@
+package myhours
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxPaletteItems is the number of matching commands listed in the palette.
+const maxPaletteItems = 8
+
+// maxReportPages limits how far back in time a report page is looked up for a
+// date. 100 years of daily reports.
+const maxReportPages = 100 * 366
+
+// command is an action that can be run from the command palette.
+type command struct {
+	name string
+	// keys is the help text of the keys that run the command, if any.
+	keys string
+	desc string
+	// args describes the arguments of the command, if it takes any.
+	args string
+	// msg returns the message that runs the command with given arguments.
+	msg func(args string) (tea.Msg, error)
+}
+
+// commands returns the commands that are available right now, sorted by name.
+// Key actions are available while their keys are enabled.
+func (m MyHours) commands() []command {
+	var commands []command
+	actions := m.keys.actions()
+	for _, name := range KeyActions() {
+		binding := actions[name]
+		if name == "palette" || !binding.Enabled() {
+			continue
+		}
+		c := command{name: name, keys: binding.Help().Key, desc: binding.Help().Desc}
+		keys := binding.Keys()
+		if name == "start_task" {
+			c.args = "[" + binding.Help().Key + "]"
+		}
+		c.msg = func(args string) (tea.Msg, error) {
+			if args != "" && !slices.Contains(keys, args) {
+				return nil, fmt.Errorf("invalid argument %q for %s", args, name)
+			}
+			return actionMsg{action: name, key: args}, nil
+		}
+		commands = append(commands, c)
+	}
+	for viewID, name := range m.viewNames {
+		r, ok := reportForView(viewID)
+		if !ok {
+			continue
+		}
+		commands = append(commands, command{
+			name: strings.ToLower(name),
+			desc: "Go to " + strings.ToLower(name) + " report",
+			args: "[-N or YYYY-MM-DD]",
+			msg: func(args string) (tea.Msg, error) {
+				pageNo, err := parseReportPage(args, r.dates, m.reportNow())
+				if err != nil {
+					return nil, err
+				}
+				return gotoReportMsg{viewID: viewID, pageNo: pageNo}, nil
+			},
+		})
+	}
+	if r, ok := reportForView(m.state.activeView); ok && !m.state.reportLoading {
+		from, _ := r.dates(m.reportNow(), m.reportPageNo())
+		name := fmt.Sprintf("myhours-%s-%s.csv", strings.ToLower(m.viewNames[m.state.activeView]), from.Format(time.DateOnly))
+		commands = append(commands, command{
+			name: "export",
+			desc: "Export this report to CSV",
+			args: "[file]",
+			msg: func(args string) (tea.Msg, error) {
+				return exportReportMsg{path: cmp.Or(args, name)}, nil
+			},
+		})
+	}
+	if m.state.activeRecord.Active() {
+		commands = append(commands, command{
+			name: "notes",
+			desc: "Set notes of the task",
+			args: "<text>",
+			msg: func(args string) (tea.Msg, error) {
+				return setNotesMsg{notes: args}, nil
+			},
+		})
+	}
+	slices.SortFunc(commands, func(a, b command) int { return strings.Compare(a.name, b.name) })
+	return commands
+}
+
+// splitCommand splits palette input into the command name and its arguments.
+func splitCommand(s string) (string, string) {
+	name, args, _ := strings.Cut(strings.TrimSpace(s), " ")
+	return name, strings.TrimSpace(args)
+}
+
+// fuzzyScore returns how well query matches text. Characters of query must
+// appear in text in the same order, ignoring case. Matches at the start of
+// words and consecutive matches score higher.
+//
+// Returns false if text does not match.
+func fuzzyScore(query, text string) (int, bool) {
+	var (
+		q     = []rune(strings.ToLower(query))
+		t     = []rune(strings.ToLower(text))
+		score int
+		prev  = -2
+		qi    int
+	)
+	for i, r := range t {
+		if qi == len(q) {
+			break
+		}
+		if r != q[qi] {
+			continue
+		}
+		score++
+		if i == prev+1 {
+			score += 2
+		}
+		if i == 0 || !unicode.IsLetter(t[i-1]) && !unicode.IsDigit(t[i-1]) {
+			score += 3
+		}
+		prev = i
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	return score, true
+}
+
+// matchCommands returns the commands matching query, best matches first. Name
+// matches weigh more than description matches. Empty query matches all.
+func matchCommands(commands []command, query string) []command {
+	type match struct {
+		command
+		score int
+	}
+	var matches []match
+	for _, c := range commands {
+		nameScore, nameOK := fuzzyScore(query, c.name)
+		descScore, descOK := fuzzyScore(query, c.desc)
+		if !nameOK && !descOK {
+			continue
+		}
+		score := max(nameScore*2, descScore)
+		if c.name == strings.ToLower(query) {
+			score += 100
+		}
+		matches = append(matches, match{command: c, score: score})
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return cmp.Compare(b.score, a.score) })
+	res := make([]command, len(matches))
+	for i := range matches {
+		res[i] = matches[i].command
+	}
+	return res
+}
+
+// paletteMatches returns the commands matching the palette input.
+func (m MyHours) paletteMatches() []command {
+	name, _ := splitCommand(m.state.prompt.field.Value())
+	return matchCommands(m.commands(), name)
+}
+
+// openPalette opens the command palette.
+func (m *MyHours) openPalette() tea.Cmd {
+	p := prompt{kind: promptPalette}
+	cmd := p.ask(inputCommand, "command")
+	p.field.CharLimit = 0
+	m.state.prompt = p
+	return cmd
+}
+
+// runCommand runs the command selected in the palette, by sending its message.
+func (m MyHours) runCommand() (MyHours, tea.Cmd) {
+	p := &m.state.prompt
+	matches := m.paletteMatches()
+	if len(matches) == 0 {
+		p.err = "no matching command"
+		return m, nil
+	}
+	_, args := splitCommand(p.field.Value())
+	msg, err := matches[min(p.choice, len(matches)-1)].msg(args)
+	if err != nil {
+		p.err = err.Error()
+		return m, nil
+	}
+	m.closePrompt()
+	return m, func() tea.Msg { return msg }
+}
+
+// reportForView returns the report shown in given view. Returns false if the
+// view is not a report view.
+func reportForView(viewID int) (report, bool) {
+	switch viewID {
+	case 1: // Daily
+		return reportDaily, true
+	case 2: // Weekly
+		return reportWeekly, true
+	case 3: // Monthly
+		return reportMonthly, true
+	case 4: // Yearly
+		return reportYearly, true
+	}
+	return report{}, false
+}
+
+// parseReportPage parses the report page to go to. Input is either the number
+// of pages back in time, like -2, or a date to find the page of. Empty input
+// is the latest page.
+func parseReportPage(s string, dates reportDatesFunc, now time.Time) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n > 0 {
+			n = -n
+		}
+		return n, nil
+	}
+	date, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return 0, fmt.Errorf("invalid page %q, use -N or YYYY-MM-DD", s)
+	}
+	if date.After(now) {
+		return 0, errors.New("date can not be in the future")
+	}
+	for pageNo := 0; pageNo > -maxReportPages; pageNo-- {
+		if from, _ := dates(now, pageNo); !date.Before(from) {
+			return pageNo, nil
+		}
+	}
+	return 0, fmt.Errorf("date %s is too far in the past", s)
+}
+
+// gotoReport switches to given report view and page.
+func (m *MyHours) gotoReport(viewID, pageNo int) tea.Cmd {
+	m.state.activeView = viewID
+	m.state.reportPage[viewID] = pageNo
+	m.enableViewKeys(viewID)
+	m.state.reportCursor = reportCursor{}
+	return m.updateReportData()
+}
+
+// exportReport returns a command that writes the report in view into a CSV
+// file. Rows are written in report order.
+func (m MyHours) exportReport(path string) tea.Cmd {
+	records := append([][]string{m.state.reportHeaders}, m.state.reportRows...)
+	return func() tea.Msg {
+		if err := writeCSV(path, records); err != nil {
+			m.l.Error("failed to export report", slog.String("error", err.Error()))
+			return errorMsg{err: fmt.Errorf("exporting report failed: %w", err)}
+		}
+		return statusMsg{text: "report exported to " + path}
+	}
+}
+
+// writeCSV writes records into a new CSV file at path.
+func writeCSV(path string, records [][]string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("os.Create: %w", err)
+	}
+	defer func() {
+		if cerr := f.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("f.Close: %w", cerr)
+		}
+	}()
+	w := csv.NewWriter(f)
+	if err = w.WriteAll(records); err != nil {
+		return fmt.Errorf("csv.WriteAll: %w", err)
+	}
+	return nil
+}
+
+// renderPalette renders the commands matching the palette input, with the
+// selected command highlighted.
+func (m MyHours) renderPalette(width int) string {
+	matches := m.paletteMatches()
+	if len(matches) == 0 {
+		return "no matching command\n"
+	}
+	choice := min(m.state.prompt.choice, len(matches)-1)
+	// keep the selected command in view.
+	offset := max(0, choice-maxPaletteItems+1)
+	var doc strings.Builder
+	line := lipgloss.NewStyle().MaxWidth(width)
+	for i, c := range matches[offset:min(len(matches), offset+maxPaletteItems)] {
+		name := lipgloss.NewStyle().Width(14).Render(c.name)
+		if i+offset == choice {
+			doc.WriteString(m.styles.glyphs.navActive)
+			name = lipgloss.NewStyle().Bold(true).Width(14).Render(c.name)
+		} else {
+			doc.WriteString(strings.Repeat(" ", lipgloss.Width(m.styles.glyphs.navActive)))
+		}
+		text := name + " " + c.desc
+		if c.args != "" {
+			text += " " + m.styles.help.ShortDesc.Render(c.args)
+		}
+		if c.keys != "" {
+			text += " " + m.styles.help.ShortKey.Render("("+c.keys+")")
+		}
+		doc.WriteString(line.Render(text))
+		doc.WriteString("\n")
+	}
+	return doc.String()
+}
@@ -0,0 +1,560 @@
+package myhours
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// promptKind identifies the prompt shown to the user.
+type promptKind int
+
+const (
+	// promptNone means no prompt is open.
+	promptNone promptKind = iota
+	// promptForgotten asks what to do with a timer that seems to have been
+	// forgotten running.
+	promptForgotten
+	// promptSwitch asks for the details of the record to switch to.
+	promptSwitch
+	// promptTimebox asks for the length of pomodoros for the active record.
+	promptTimebox
+	// promptStartAt asks for the time to start the timer from.
+	promptStartAt
+	// promptStopAt asks for the time to stop the timer at.
+	promptStopAt
+	// promptPalette asks for a command to run.
+	promptPalette
+	// promptSetting asks for a new value of a setting.
+	promptSetting
+)
+
+// promptInput identifies what a prompt is asking input for.
+type promptInput int
+
+const (
+	// inputNone means prompt is not waiting for text input.
+	inputNone promptInput = iota
+	// inputEndTime asks for the real end time of the active record.
+	inputEndTime
+	// inputSplitTime asks for the time to split the active record at.
+	inputSplitTime
+	// inputNotes asks for notes of a record.
+	inputNotes
+	// inputTimebox asks for the length of timeboxed work.
+	inputTimebox
+	// inputStartTime asks for the start time of a new record.
+	inputStartTime
+	// inputCommand asks for a command and its arguments.
+	inputCommand
+	// inputSetting asks for the value of a setting.
+	inputSetting
+)
+
+// prompt is a modal dialog. While a prompt is open, it receives all key input.
+type prompt struct {
+	kind  promptKind
+	input promptInput
+	field textinput.Model
+	// choice is the index of the selected option, for prompts with options.
+	// In the command palette, it's the index of the selected matching command.
+	choice int
+	// notBefore is the earliest time accepted for starting a record, so that
+	// it doesn't overlap the previous one.
+	notBefore time.Time
+	// loading is set while the previous record is being loaded for notBefore.
+	// The start time can't be checked until it's known.
+	loading bool
+	// setting is the setting being edited.
+	setting Setting
+	err     string
+}
+
+// promptKeys are the keys used in prompts. These are not configurable, as they
+// are only active while a prompt is open.
+type promptKeys struct {
+	confirm key.Binding
+	cancel  key.Binding
+	setEnd  key.Binding
+	keep    key.Binding
+	split   key.Binding
+	prev    key.Binding
+	next    key.Binding
+}
+
+func newPromptKeys() promptKeys {
+	return promptKeys{
+		confirm: key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "Confirm")),
+		cancel:  key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "Cancel")),
+		setEnd:  key.NewBinding(key.WithKeys("e"), key.WithHelp("e", "Set end time")),
+		keep:    key.NewBinding(key.WithKeys("k", "esc"), key.WithHelp("k", "Keep running")),
+		split:   key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "Split and continue")),
+		prev:    key.NewBinding(key.WithKeys("up"), key.WithHelp("↑", "Previous")),
+		next:    key.NewBinding(key.WithKeys("down"), key.WithHelp("↓", "Next")),
+	}
+}
+
+// timeFormats are the accepted formats for entering times. Times without a
+// date are on the date of a reference time.
+var timeFormats = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"15:04:05",
+	"15:04",
+}
+
+// parseTime parses a time entered by the user in local time. If the input has
+// no date, the date of ref is used.
+func parseTime(s string, ref time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeFormats {
+		t, err := time.ParseInLocation(layout, s, time.Local)
+		if err != nil {
+			continue
+		}
+		if !strings.Contains(layout, "2006") {
+			y, m, d := ref.In(time.Local).Date()
+			t = time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), 0, time.Local)
+		}
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, use HH:MM or YYYY-MM-DD HH:MM", s)
+}
+
+// forgotten returns true if the record is active and has been running longer
+// than the threshold, or since before today.
+func forgotten(record Record, now time.Time, threshold time.Duration) bool {
+	if !record.Active() {
+		return false
+	}
+	if threshold > 0 && now.Sub(record.Start) > threshold {
+		return true
+	}
+	sy, sm, sd := record.Start.In(time.Local).Date()
+	ny, nm, nd := now.In(time.Local).Date()
+	return sy != ny || sm != nm || sd != nd
+}
+
+// checkForgotten opens the forgotten timer prompt if the active record looks
+// like it has been forgotten running.
+func (m *MyHours) checkForgotten() {
+	if m.state.prompt.kind != promptNone {
+		return
+	}
+	if forgotten(m.state.activeRecord, m.now(), m.settings.ForgottenTimerThreshold) {
+		m.state.prompt = prompt{kind: promptForgotten}
+	}
+}
+
+// closePrompt closes the open prompt.
+func (m *MyHours) closePrompt() {
+	m.state.prompt = prompt{}
+}
+
+// updatePrompt handles key input while a prompt is open.
+func (m MyHours) updatePrompt(msg tea.KeyMsg) (MyHours, tea.Cmd) {
+	keys := newPromptKeys()
+	p := &m.state.prompt
+	// options are selected with arrow keys, so that they work together with
+	// text input.
+	if p.kind == promptSwitch {
+		switch {
+		case key.Matches(msg, keys.prev):
+			p.choice = decWrap(p.choice, 0, len(m.categories)-1)
+			return m, nil
+		case key.Matches(msg, keys.next):
+			p.choice = incWrap(p.choice, 0, len(m.categories)-1)
+			return m, nil
+		}
+	}
+	if p.kind == promptPalette {
+		n := len(m.paletteMatches())
+		switch {
+		case key.Matches(msg, keys.prev):
+			p.choice = decWrap(p.choice, 0, n-1)
+			return m, nil
+		case key.Matches(msg, keys.next):
+			p.choice = incWrap(p.choice, 0, n-1)
+			return m, nil
+		}
+	}
+	// text input takes all keys, except the ones for confirming or cancelling.
+	if p.input != inputNone {
+		switch {
+		case key.Matches(msg, keys.cancel) && p.kind != promptForgotten:
+			m.closePrompt()
+			return m, nil
+		case key.Matches(msg, keys.cancel):
+			p.input = inputNone
+			p.err = ""
+			return m, nil
+		case key.Matches(msg, keys.confirm):
+			return m.confirmPrompt()
+		}
+		var cmd tea.Cmd
+		value := p.field.Value()
+		p.field, cmd = p.field.Update(msg)
+		// matching commands change with the input, start from the best match.
+		if p.kind == promptPalette && p.field.Value() != value {
+			p.choice = 0
+			p.err = ""
+		}
+		return m, cmd
+	}
+	switch p.kind {
+	case promptForgotten:
+		switch {
+		case key.Matches(msg, keys.setEnd):
+			return m, p.ask(inputEndTime, "end time")
+		case key.Matches(msg, keys.split):
+			return m, p.ask(inputSplitTime, "split at")
+		case key.Matches(msg, keys.keep):
+			m.closePrompt()
+		}
+	}
+	return m, nil
+}
+
+// ask switches the prompt to text input.
+func (p *prompt) ask(input promptInput, placeholder string) tea.Cmd {
+	p.input = input
+	p.err = ""
+	p.field = textinput.New()
+	p.field.Placeholder = placeholder
+	p.field.CharLimit = 19
+	return p.field.Focus()
+}
+
+// confirmPrompt acts on the value entered into prompt.
+func (m MyHours) confirmPrompt() (MyHours, tea.Cmd) {
+	p := &m.state.prompt
+	switch p.input {
+	case inputEndTime, inputSplitTime:
+		record := m.state.activeRecord
+		at, err := parseTime(p.field.Value(), record.Start)
+		if p.kind == promptStopAt {
+			at, err = parseWhen(p.field.Value(), m.now())
+		}
+		if err == nil {
+			err = validateEnd(record, at, m.now())
+		}
+		if err != nil {
+			p.err = err.Error()
+			return m, nil
+		}
+		input := p.input
+		m.closePrompt()
+		if input == inputSplitTime {
+			return m, m.splitRecord(record, at, m.now())
+		}
+		return m, m.timer.stopAt(at)
+	case inputStartTime:
+		if p.loading {
+			p.err = "previous record is still being loaded"
+			return m, nil
+		}
+		now := m.now()
+		at, err := parseWhen(p.field.Value(), now)
+		if err == nil {
+			err = validateStart(at, p.notBefore, now)
+		}
+		if err != nil {
+			p.err = err.Error()
+			return m, nil
+		}
+		m.closePrompt()
+		// a finished record stays on display until a new one starts, so start
+		// from a clean record.
+		m.setActiveRecord(Record{CategoryID: m.state.activeRecord.CategoryID})
+		return m, m.timer.startAt(at)
+	case inputNotes:
+		if len(m.categories) == 0 {
+			p.err = "no categories available"
+			return m, nil
+		}
+		categoryID := m.categories[min(p.choice, len(m.categories)-1)].ID
+		notes := strings.TrimSpace(p.field.Value())
+		m.closePrompt()
+		return m, m.switchRecord(m.now(), categoryID, notes)
+	case inputCommand:
+		return m.runCommand()
+	case inputSetting:
+		return m.saveSetting()
+	case inputTimebox:
+		work, err := parseTimebox(p.field.Value())
+		if err != nil {
+			p.err = err.Error()
+			return m, nil
+		}
+		m.closePrompt()
+		if work == 0 {
+			m.state.timebox = timebox{}
+			return m, m.setStatus("timebox cleared", false)
+		}
+		now := m.now()
+		m.state.timebox = newTimebox(work, now)
+		commands := []tea.Cmd{m.setStatus("timebox of "+shortDuration(work)+" started", false)}
+		// timeboxed work starts right away, also from a break.
+		if m.state.activeRecord.Paused() {
+			commands = append(commands, m.timer.resume())
+		}
+		return m, tea.Batch(commands...)
+	}
+	return m, nil
+}
+
+// parseWhen parses a point in time entered by the user. Input is either a
+// duration before now, like 15m, or a time accepted by parseTime.
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d).Truncate(time.Second), nil
+	}
+	t, err := parseTime(s, now)
+	if err != nil {
+		return t, fmt.Errorf("invalid time %q, use HH:MM or time ago like 15m", s)
+	}
+	return t, nil
+}
+
+// validateStart checks that at is a valid start time for a new record. Start
+// must not be before notBefore, the end of the previous record.
+func validateStart(at, notBefore, now time.Time) error {
+	switch {
+	case at.After(now):
+		return errors.New("start time can not be in the future")
+	case at.Before(notBefore):
+		return errors.New("start time overlaps previous record, which ended " + notBefore.In(time.Local).Format(time.DateTime))
+	}
+	return nil
+}
+
+// openStartAtPrompt opens the prompt for starting the timer retroactively. The
+// end of the previous record is loaded for validating the start time, the
+// prompt can't be confirmed before that.
+func (m *MyHours) openStartAtPrompt() tea.Cmd {
+	p := prompt{kind: promptStartAt, loading: true}
+	if m.state.activeRecord.Finished() {
+		p.notBefore = m.state.activeRecord.End
+	}
+	cmd := p.ask(inputStartTime, "15m or HH:MM")
+	m.state.prompt = p
+	return tea.Batch(cmd, m.loadLastRecord())
+}
+
+// openStopAtPrompt opens the prompt for stopping the timer retroactively.
+func (m *MyHours) openStopAtPrompt() tea.Cmd {
+	p := prompt{kind: promptStopAt}
+	cmd := p.ask(inputEndTime, "15m or HH:MM")
+	m.state.prompt = p
+	return cmd
+}
+
+// loadLastRecord returns a command that loads the last finished record.
+func (m MyHours) loadLastRecord() tea.Cmd {
+	return func() tea.Msg {
+		record, err := m.db.LastRecord(m.ctx)
+		if err != nil {
+			m.l.Error("failed to load last record", slog.String("error", err.Error()))
+			return lastRecordMsg{err: fmt.Errorf("loading previous record failed: %w", err)}
+		}
+		if record == nil {
+			return lastRecordMsg{}
+		}
+		return lastRecordMsg{record: *record}
+	}
+}
+
+// parseTimebox parses the length of timeboxed work. Empty input or zero clears
+// the timebox.
+func parseTimebox(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid length %q, use for example 25m", s)
+	}
+	return d, nil
+}
+
+// openTimeboxPrompt opens the prompt for setting a timebox. Length of the
+// current timebox, or the default length, is suggested.
+func (m *MyHours) openTimeboxPrompt() tea.Cmd {
+	p := prompt{kind: promptTimebox}
+	cmd := p.ask(inputTimebox, "length")
+	work := defaultTimeboxLength
+	if m.state.timebox.phase != phaseOff {
+		work = m.state.timebox.work
+	}
+	p.field.SetValue(shortDuration(work))
+	m.state.prompt = p
+	return cmd
+}
+
+// shortDuration formats d without trailing zero units, for example 25m instead
+// of 25m0s.
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// openSwitchPrompt opens the prompt for switching to a new record. The category
+// of the active record is selected by default.
+func (m *MyHours) openSwitchPrompt() tea.Cmd {
+	p := prompt{kind: promptSwitch}
+	for i, cat := range m.categories {
+		if cat.ID == m.state.activeRecord.CategoryID {
+			p.choice = i
+		}
+	}
+	cmd := p.ask(inputNotes, "notes")
+	p.field.CharLimit = 0
+	m.state.prompt = p
+	return cmd
+}
+
+// switchRecord returns a command that ends the active record and starts a new
+// one, both at the given time.
+func (m MyHours) switchRecord(at time.Time, categoryID int64, notes string) tea.Cmd {
+	ended := m.state.activeRecord
+	return func() tea.Msg {
+		at = at.Truncate(time.Second)
+		id, err := m.db.SwitchRecord(m.ctx, at, at, categoryID, notes)
+		if err != nil {
+			m.l.Error("failed to switch record", slog.String("error", err.Error()))
+			return errorMsg{err: fmt.Errorf("switching task failed: %w", err)}
+		}
+		ended.End = at
+		return recordSwitchedMsg{
+			ended:   ended,
+			started: Record{ID: id, Start: at, CategoryID: categoryID, Notes: notes},
+		}
+	}
+}
+
+// validateEnd checks that at is a valid end time for the record.
+func validateEnd(record Record, at, now time.Time) error {
+	switch {
+	case !at.After(record.Start):
+		return errors.New("end time must be after start " + record.Start.Format(time.DateTime))
+	case at.After(now):
+		return errors.New("end time can not be in the future")
+	case record.Paused() && at.Before(record.Breaks[len(record.Breaks)-1].Start):
+		return errors.New("end time must be after start of the break that is on")
+	}
+	return nil
+}
+
+// splitRecord returns a command that ends the active record at given time, and
+// starts a new record with the same details from start. Both are done at once,
+// or not at all.
+func (m MyHours) splitRecord(record Record, at, start time.Time) tea.Cmd {
+	return func() tea.Msg {
+		started := Record{Start: start.Truncate(time.Second), CategoryID: record.CategoryID, Notes: record.Notes}
+		id, err := m.db.SwitchRecord(m.ctx, at, started.Start, started.CategoryID, started.Notes)
+		if err != nil {
+			m.l.Error("failed to split record", slog.String("error", err.Error()))
+			return errorMsg{err: fmt.Errorf("splitting record failed: %w", err)}
+		}
+		started.ID = id
+		ended := record
+		ended.End = at
+		ended.Breaks = endBreaks(ended.Breaks, at)
+		return recordSwitchedMsg{ended: ended, started: started}
+	}
+}
+
+// renderPrompt renders the open prompt.
+func (m MyHours) renderPrompt(width, _ int) string {
+	var (
+		keys = newPromptKeys()
+		p    = m.state.prompt
+		doc  strings.Builder
+		help []key.Binding
+	)
+	switch p.kind {
+	case promptForgotten:
+		record := m.state.activeRecord
+		doc.WriteString("Timer has been running since\n")
+		doc.WriteString(record.Start.Format(time.DateTime))
+		doc.WriteString(" (")
+		doc.WriteString(m.now().Sub(record.Start).Truncate(time.Minute).String())
+		doc.WriteString(").\nDid you forget to stop it?\n")
+		help = []key.Binding{keys.setEnd, keys.keep, keys.split}
+	case promptSwitch:
+		doc.WriteString("Switch to a new task\n\n")
+		for i, cat := range m.categories {
+			style := lipgloss.NewStyle().Foreground(m.styles.categoryColor(cat))
+			if i == p.choice {
+				doc.WriteString(m.styles.glyphs.navActive)
+				style = style.Bold(true)
+			} else {
+				doc.WriteString(strings.Repeat(" ", lipgloss.Width(m.styles.glyphs.navActive)))
+			}
+			doc.WriteString(style.Render(cat.Name))
+			doc.WriteString("\n")
+		}
+	case promptStartAt:
+		doc.WriteString("Start the timer retroactively.\n")
+		if p.loading && p.err == "" {
+			doc.WriteString("Loading previous record…\n")
+		} else if !p.notBefore.IsZero() {
+			doc.WriteString("Previous record ended ")
+			doc.WriteString(p.notBefore.In(time.Local).Format(time.DateTime))
+			doc.WriteString(".\n")
+		}
+	case promptStopAt:
+		doc.WriteString("Stop the timer retroactively. Timer started\n")
+		doc.WriteString(m.state.activeRecord.Start.In(time.Local).Format(time.DateTime))
+		doc.WriteString(".\n")
+	case promptSetting:
+		if spec, ok := LookupSetting(p.setting); ok {
+			doc.WriteString("Edit " + spec.Key.String() + " (" + spec.Type + ")\n")
+			doc.WriteString(spec.Description + "\n")
+		}
+	case promptPalette:
+		doc.WriteString("Run a command, with arguments after a space.\n")
+	case promptTimebox:
+		doc.WriteString("Work in timeboxes of given length, with breaks\n")
+		doc.WriteString("in between. Leave empty to clear the timebox.\n")
+	}
+	if p.input != inputNone {
+		doc.WriteString("\n")
+		doc.WriteString(p.field.View())
+		doc.WriteString("\n")
+		help = []key.Binding{keys.confirm, keys.cancel}
+		if p.kind == promptSwitch || p.kind == promptPalette {
+			help = []key.Binding{keys.prev, keys.next, keys.confirm, keys.cancel}
+		}
+	}
+	w := min(50, width)
+	if p.kind == promptPalette {
+		w = min(72, width)
+		doc.WriteString("\n")
+		doc.WriteString(m.renderPalette(w - styleTimerContainer.GetHorizontalFrameSize()))
+	}
+	if p.err != "" {
+		doc.WriteString(m.styles.statusError.Render(p.err))
+		doc.WriteString("\n")
+	}
+	var box strings.Builder
+	box.WriteString(styleTimerContainer.Width(w).Render(doc.String()))
+	box.WriteString("\n")
+	box.WriteString(m.renderShortHelp(width, help...))
+	return box.String()
+}
@@ -0,0 +1,65 @@
+package myhours
+
+import "time"
+
+// reportCacheSize is the number of report pages kept in the cache.
+const reportCacheSize = 32
+
+// reportKey identifies a report page.
+type reportKey struct {
+	viewID     int
+	pageNo     int
+	categoryID int64
+}
+
+// reportCacheEntry is a cached report page. Pages are numbered relative to the
+// date reports are shown as of, and options change the columns, so an entry is
+// only valid for the same start date and options.
+type reportCacheEntry struct {
+	from  time.Time
+	opts  reportOptions
+	table reportTable
+}
+
+// reportCache keeps the most recently used report pages, so that paging back
+// and forth doesn't go to the database every time. Pages are cached until
+// records change. Used from Update only, so not safe for concurrent use.
+type reportCache struct {
+	pages *lru[reportKey, reportCacheEntry]
+	// pending are the pages being prefetched.
+	pending map[reportKey]bool
+	// generation is incremented when the cache is invalidated, so that pages
+	// loaded before that are not stored.
+	generation int
+}
+
+func newReportCache() *reportCache {
+	return &reportCache{
+		pages:   newLRU[reportKey, reportCacheEntry](reportCacheSize),
+		pending: make(map[reportKey]bool),
+	}
+}
+
+// get returns the page cached by key, if it starts from given date and was
+// built with given options.
+func (c *reportCache) get(key reportKey, from time.Time, opts reportOptions) (reportTable, bool) {
+	entry, ok := c.pages.get(key)
+	if !ok || !entry.from.Equal(from) || entry.opts != opts {
+		return reportTable{}, false
+	}
+	return entry.table, true
+}
+
+// put caches the page loaded in given generation.
+func (c *reportCache) put(generation int, key reportKey, entry reportCacheEntry) {
+	if generation == c.generation {
+		c.pages.put(key, entry)
+	}
+}
+
+// invalidate drops all cached pages, and the results of loads in progress.
+func (c *reportCache) invalidate() {
+	c.generation++
+	c.pages.clear()
+	clear(c.pending)
+}
@@ -0,0 +1,185 @@
+package myhours
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/lipgloss/table"
+)
+
+// settingsView is the ID of the settings view, the last one after reports.
+const settingsView = 5
+
+// parseCategory finds a category by name, ignoring case, or by ID.
+func parseCategory(categories []Category, s string) (Category, error) {
+	s = strings.TrimSpace(s)
+	for _, cat := range categories {
+		if strings.EqualFold(cat.Name, s) {
+			return cat, nil
+		}
+	}
+	if id, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if cat := findCategory(categories, id); cat.ID != 0 {
+			return cat, nil
+		}
+	}
+	return Category{}, fmt.Errorf("unknown category %q", s)
+}
+
+// settingsRow is a setting listed in the settings view.
+type settingsRow struct {
+	key   string
+	kind  string
+	value string
+	desc  string
+	// known is false for settings found in the database that this version of
+	// the application doesn't know. Those can't be edited.
+	known bool
+}
+
+// settingsRows returns the settings listed in the settings view: known
+// settings first, followed by unknown settings sorted by key.
+func (m MyHours) settingsRows() []settingsRow {
+	var rows []settingsRow
+	for _, spec := range SettingSpecs() {
+		rows = append(rows, settingsRow{
+			key:   spec.Key.String(),
+			kind:  spec.Type,
+			value: m.settingValue(spec),
+			desc:  spec.Description,
+			known: true,
+		})
+	}
+	for _, key := range slices.Sorted(maps.Keys(m.settings.Unknown)) {
+		rows = append(rows, settingsRow{
+			key:   key,
+			kind:  "unknown",
+			value: m.settings.Unknown[key],
+			desc:  "Not supported by this version of the application.",
+		})
+	}
+	return rows
+}
+
+// settingValue returns the current value of a setting, formatted for editing.
+// Categories are shown by name.
+func (m MyHours) settingValue(spec SettingSpec) string {
+	if spec.Type == "category" {
+		id, err := strconv.ParseInt(spec.Format(m.settings), 10, 64)
+		if err == nil {
+			return findCategory(m.categories, id).Name
+		}
+	}
+	return spec.Format(m.settings)
+}
+
+// scrollSettings keeps the selected setting within the list, and visible.
+func (m *MyHours) scrollSettings() {
+	capacity := tableRowCapacity(settingsTableHeight(m.contentHeight()), 0)
+	m.state.settingsCursor = m.state.settingsCursor.scroll(len(m.settingsRows()), capacity)
+}
+
+// openSettingPrompt opens the prompt for editing the selected setting, with
+// the current value filled in.
+func (m *MyHours) openSettingPrompt() tea.Cmd {
+	rows := m.settingsRows()
+	if len(rows) == 0 {
+		return nil
+	}
+	row := rows[min(m.state.settingsCursor.row, len(rows)-1)]
+	if !row.known {
+		return m.setStatus(row.key+" is not supported, it can't be edited", true)
+	}
+	p := prompt{kind: promptSetting, setting: Setting(row.key)}
+	cmd := p.ask(inputSetting, row.kind)
+	p.field.CharLimit = 0
+	p.field.SetValue(row.value)
+	m.state.prompt = p
+	return cmd
+}
+
+// saveSetting validates the value entered for the setting being edited, and
+// returns a command that stores it. Categories can be entered by name or ID.
+func (m MyHours) saveSetting() (MyHours, tea.Cmd) {
+	p := &m.state.prompt
+	spec, ok := LookupSetting(p.setting)
+	if !ok {
+		p.err = "unknown setting " + p.setting.String()
+		return m, nil
+	}
+	value := strings.TrimSpace(p.field.Value())
+	if spec.Type == "category" {
+		cat, err := parseCategory(m.categories, value)
+		if err != nil {
+			p.err = err.Error()
+			return m, nil
+		}
+		value = strconv.FormatInt(cat.ID, 10)
+	}
+	_, stored, err := m.settings.Set(spec.Key, value, m.categories)
+	if err != nil {
+		p.err = err.Error()
+		return m, nil
+	}
+	m.closePrompt()
+	cmd := m.updateSetting(spec.Key, stored, func(s *Settings) {
+		_ = spec.Parse(stored, s)
+	})
+	return m, cmd
+}
+
+// settingsTableHeight returns the height available for the settings table in
+// a view of given height. Title and short help take a line each, description
+// takes two.
+func settingsTableHeight(height int) int {
+	return height - styleReportContainer.GetVerticalFrameSize() - 4
+}
+
+// renderSettings renders the list of settings, with the description of the
+// selected setting below it.
+func (m MyHours) renderSettings(width, height int) string {
+	var (
+		container   = styleReportContainer.Width(width)
+		tableWidth  = width - container.GetHorizontalFrameSize()
+		tableHeight = settingsTableHeight(height)
+		rows        = m.settingsRows()
+		cursor      = m.state.settingsCursor.scroll(len(rows), tableRowCapacity(tableHeight, 0))
+		visible     = rows[cursor.offset:min(len(rows), cursor.offset+tableRowCapacity(tableHeight, 0))]
+		data        [][]string
+	)
+	for _, row := range visible {
+		data = append(data, []string{row.key, row.kind, row.value})
+	}
+	tbl := table.New().Width(tableWidth).Height(tableHeight).
+		Headers("Setting", "Type", "Value").
+		Rows(data...).
+		StyleFunc(func(r, _ int) lipgloss.Style {
+			switch {
+			case r < 0:
+				return m.styles.tableCell
+			case r == cursor.row-cursor.offset:
+				return m.styles.tableCell.Inherit(m.styles.tableCursor)
+			case !visible[r].known:
+				return m.styles.tableCell.Faint(true)
+			}
+			return m.styles.tableCell
+		})
+	var doc strings.Builder
+	doc.WriteString(styleReportTitle.Render("Settings"))
+	doc.WriteString("\n")
+	doc.WriteString(tbl.Render())
+	doc.WriteString("\n")
+	var desc string
+	if len(rows) > 0 {
+		desc = rows[cursor.row].desc
+	}
+	doc.WriteString(lipgloss.NewStyle().Width(tableWidth).Height(2).MaxHeight(2).Render(desc))
+	doc.WriteString("\n")
+	doc.WriteString(m.renderShortHelp(width, m.keys.reportRowUp, m.keys.reportRowDown, m.keys.editSetting))
+	return container.Render(doc.String())
+}
@@ -1,35 +1,248 @@
 package myhours
 
 import (
+	"slices"
+
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Layout styles. These don't define any colors, so they are not part of a theme.
 var (
-	navColorBG         = lipgloss.AdaptiveColor{Light: "254", Dark: "16"}
-	navColorFGActive   = lipgloss.AdaptiveColor{Light: "22", Dark: "40"}
-	navColorFGInactive = lipgloss.AdaptiveColor{Light: "2", Dark: "243"}
-	styleLoader        = lipgloss.NewStyle().Faint(true).Align(lipgloss.Center)
-	styleNavCap        = lipgloss.NewStyle().Foreground(navColorBG)
-	styleModeIndicator = lipgloss.NewStyle().Background(navColorBG).Foreground(navColorFGInactive).Padding(0, 0, 0, 1)
-	styleNavJoiner     = lipgloss.NewStyle().Background(navColorBG).Foreground(navColorFGInactive)
-	styleNavInactive   = lipgloss.NewStyle().Background(navColorBG).Foreground(navColorFGInactive).Padding(0, 1)
-	styleNavActive     = lipgloss.NewStyle().Background(navColorBG).Foreground(navColorFGActive).Padding(0, 1)
-	styleWindow        = lipgloss.NewStyle().Border(lipgloss.ASCIIBorder(), false).Padding(0, 0, 0, 0).Margin(0, 0).Align(lipgloss.Center, lipgloss.Bottom)
-	styleTimerLabel    = lipgloss.NewStyle().Bold(true).Width(10)
-	styleTableCell     = lipgloss.NewStyle().Padding(0, 1)
-	styleTableSumRow   = styleTableCell.Background(lipgloss.AdaptiveColor{Dark: "235", Light: "250"}).Foreground(lipgloss.AdaptiveColor{Dark: "195", Light: "20"})
-	styleHelp          = help.Styles{
-		Ellipsis:       lipgloss.NewStyle().Foreground(navColorFGInactive),
-		ShortKey:       lipgloss.NewStyle().Bold(true).Foreground(lipgloss.AdaptiveColor{Light: "4", Dark: "33"}),
-		ShortDesc:      lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "238", Dark: "250"}),
-		ShortSeparator: lipgloss.NewStyle().Foreground(navColorFGInactive),
-		FullKey:        lipgloss.NewStyle().Bold(true).Foreground(lipgloss.AdaptiveColor{Light: "4", Dark: "33"}),
-		FullDesc:       lipgloss.NewStyle().Foreground(lipgloss.AdaptiveColor{Light: "238", Dark: "250"}),
-		FullSeparator:  lipgloss.NewStyle().Foreground(navColorFGInactive),
-	}
+	styleWindow          = lipgloss.NewStyle().Border(lipgloss.ASCIIBorder(), false).Padding(0, 0, 0, 0).Margin(0, 0).Align(lipgloss.Center, lipgloss.Bottom)
 	styleShortHelp       = lipgloss.NewStyle().Align(lipgloss.Center)
 	styleTimerContainer  = lipgloss.NewStyle().Border(lipgloss.DoubleBorder()).Padding(1, 2)
 	styleReportContainer = lipgloss.NewStyle().Padding(1, 1, 0, 1)
 	styleReportTitle     = lipgloss.NewStyle().Margin(0, 2)
 )
+
+// Color is a color definition for both light and dark terminals. Values are
+// either ANSI color numbers or hex codes. Empty value means no color.
+type Color struct {
+	Light string `json:"light"`
+	Dark  string `json:"dark"`
+}
+
+func (c Color) adaptive() lipgloss.AdaptiveColor {
+	return lipgloss.AdaptiveColor{Light: c.Light, Dark: c.Dark}
+}
+
+// Glyph sets available for themes.
+const (
+	// GlyphsNerdFont uses Nerd Font glyphs for decorations. Default.
+	GlyphsNerdFont = "nerdfont"
+	// GlyphsPlain uses plain characters only, for terminals without Nerd Fonts.
+	GlyphsPlain = "plain"
+)
+
+// Theme defines the colors and glyphs used for rendering the application.
+type Theme struct {
+	// Name of the theme.
+	Name string `json:"name"`
+	// Glyphs selects the glyph set, GlyphsNerdFont or GlyphsPlain.
+	Glyphs string `json:"glyphs"`
+	// NavBackground is the background color of the navigation bar.
+	NavBackground Color `json:"navBackground"`
+	// NavActive is the text color of the active view in navigation.
+	NavActive Color `json:"navActive"`
+	// NavInactive is the text color of inactive views in navigation.
+	NavInactive Color `json:"navInactive"`
+	// TableSumBackground is the background color of table total rows.
+	TableSumBackground Color `json:"tableSumBackground"`
+	// TableSumForeground is the text color of table total rows.
+	TableSumForeground Color `json:"tableSumForeground"`
+	// HelpKey is the text color of keys in help.
+	HelpKey Color `json:"helpKey"`
+	// HelpDesc is the text color of key descriptions in help.
+	HelpDesc Color `json:"helpDesc"`
+	// HelpSeparator is the text color of separators in help.
+	HelpSeparator Color `json:"helpSeparator"`
+	// TimerLabel is the text color of the labels in timer view.
+	TimerLabel Color `json:"timerLabel"`
+	// StatusInfo is the text color of informational notifications.
+	StatusInfo Color `json:"statusInfo"`
+	// StatusError is the text color of error notifications.
+	StatusError Color `json:"statusError"`
+	// CategoryColors enables using the category colors from database.
+	CategoryColors bool `json:"categoryColors"`
+	// Categories overrides category foreground colors by category ID.
+	Categories map[int64]Color `json:"categories"`
+}
+
+// themes contains the built-in themes.
+var themes = []Theme{
+	{
+		Name:               "default",
+		Glyphs:             GlyphsNerdFont,
+		NavBackground:      Color{Light: "254", Dark: "16"},
+		NavActive:          Color{Light: "22", Dark: "40"},
+		NavInactive:        Color{Light: "2", Dark: "243"},
+		TableSumBackground: Color{Light: "250", Dark: "235"},
+		TableSumForeground: Color{Light: "20", Dark: "195"},
+		HelpKey:            Color{Light: "4", Dark: "33"},
+		HelpDesc:           Color{Light: "238", Dark: "250"},
+		HelpSeparator:      Color{Light: "2", Dark: "243"},
+		StatusInfo:         Color{Light: "22", Dark: "40"},
+		StatusError:        Color{Light: "160", Dark: "203"},
+		CategoryColors:     true,
+	},
+	{
+		Name:               "high-contrast",
+		Glyphs:             GlyphsNerdFont,
+		NavBackground:      Color{Light: "255", Dark: "0"},
+		NavActive:          Color{Light: "0", Dark: "15"},
+		NavInactive:        Color{Light: "238", Dark: "250"},
+		TableSumBackground: Color{Light: "0", Dark: "15"},
+		TableSumForeground: Color{Light: "15", Dark: "0"},
+		HelpKey:            Color{Light: "0", Dark: "15"},
+		HelpDesc:           Color{Light: "0", Dark: "15"},
+		HelpSeparator:      Color{Light: "238", Dark: "250"},
+		TimerLabel:         Color{Light: "0", Dark: "15"},
+		StatusInfo:         Color{Light: "0", Dark: "15"},
+		StatusError:        Color{Light: "88", Dark: "9"},
+		CategoryColors:     true,
+	},
+	{
+		Name:   "monochrome",
+		Glyphs: GlyphsPlain,
+	},
+}
+
+// BuiltinTheme returns a built-in theme by name. Second return value is false if
+// no such theme exists.
+func BuiltinTheme(name string) (Theme, bool) {
+	i := slices.IndexFunc(themes, func(t Theme) bool { return t.Name == name })
+	if i < 0 {
+		return Theme{}, false
+	}
+	t := themes[i]
+	// copy the map so that modifications don't leak into the built-in theme.
+	t.Categories = make(map[int64]Color, len(themes[i].Categories))
+	for id, c := range themes[i].Categories {
+		t.Categories[id] = c
+	}
+	return t, true
+}
+
+// ThemeNames returns the names of all built-in themes.
+func ThemeNames() []string {
+	names := make([]string, len(themes))
+	for i, t := range themes {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// glyphs used for decorations in the application.
+type glyphs struct {
+	navActive   string
+	navCapLeft  string
+	navCapRight string
+	navDivider  string
+	navJoiner   string
+	running     string
+	paused      string
+	idle        string
+	done        string
+	pinned      string
+}
+
+var (
+	glyphsNerdFont = glyphs{
+		navActive:   "\uE617 ",
+		navCapLeft:  "\uE0BA",
+		navCapRight: "\uE0BC",
+		navDivider:  "│",
+		navJoiner:   "╱",
+		running:     "🕒 ",
+		paused:      "⏸ ",
+		idle:        "😴 ",
+		done:        "✅ ",
+		pinned:      "\uF435 ",
+	}
+	glyphsPlain = glyphs{
+		navActive:   "> ",
+		navCapLeft:  " ",
+		navCapRight: " ",
+		navDivider:  "|",
+		navJoiner:   "/",
+		running:     "* ",
+		paused:      "= ",
+		idle:        "- ",
+		done:        "+ ",
+		pinned:      "* ",
+	}
+)
+
+// styles contains the styles built from a Theme.
+type styles struct {
+	theme         Theme
+	glyphs        glyphs
+	loader        lipgloss.Style
+	navCap        lipgloss.Style
+	modeIndicator lipgloss.Style
+	navJoiner     lipgloss.Style
+	navInactive   lipgloss.Style
+	navActive     lipgloss.Style
+	timerLabel    lipgloss.Style
+	tableCell     lipgloss.Style
+	tableSumRow   lipgloss.Style
+	tableCursor   lipgloss.Style
+	statusInfo    lipgloss.Style
+	statusError   lipgloss.Style
+	help          help.Styles
+}
+
+// newStyles builds the application styles from the given theme.
+func newStyles(t Theme) styles {
+	var (
+		navBG         = t.NavBackground.adaptive()
+		navFGActive   = t.NavActive.adaptive()
+		navFGInactive = t.NavInactive.adaptive()
+		helpKey       = lipgloss.NewStyle().Bold(true).Foreground(t.HelpKey.adaptive())
+		helpDesc      = lipgloss.NewStyle().Foreground(t.HelpDesc.adaptive())
+		helpSeparator = lipgloss.NewStyle().Foreground(t.HelpSeparator.adaptive())
+		s             = styles{theme: t, glyphs: glyphsNerdFont}
+	)
+	if t.Glyphs == GlyphsPlain {
+		s.glyphs = glyphsPlain
+	}
+	s.loader = lipgloss.NewStyle().Faint(true).Align(lipgloss.Center)
+	s.navCap = lipgloss.NewStyle().Foreground(navBG)
+	s.modeIndicator = lipgloss.NewStyle().Background(navBG).Foreground(navFGInactive).Padding(0, 0, 0, 1)
+	s.navJoiner = lipgloss.NewStyle().Background(navBG).Foreground(navFGInactive)
+	s.navInactive = lipgloss.NewStyle().Background(navBG).Foreground(navFGInactive).Padding(0, 1)
+	s.navActive = lipgloss.NewStyle().Background(navBG).Foreground(navFGActive).Padding(0, 1)
+	s.timerLabel = lipgloss.NewStyle().Bold(true).Width(10).Foreground(t.TimerLabel.adaptive())
+	s.tableCell = lipgloss.NewStyle().Padding(0, 1)
+	s.tableCursor = lipgloss.NewStyle().Reverse(true)
+	s.tableSumRow = s.tableCell.Background(t.TableSumBackground.adaptive()).Foreground(t.TableSumForeground.adaptive())
+	// without colors, total rows would be indistinguishable from others.
+	if t.TableSumBackground == (Color{}) && t.TableSumForeground == (Color{}) {
+		s.tableSumRow = s.tableSumRow.Bold(true)
+	}
+	s.statusInfo = lipgloss.NewStyle().Foreground(t.StatusInfo.adaptive())
+	s.statusError = lipgloss.NewStyle().Bold(true).Foreground(t.StatusError.adaptive())
+	s.help = help.Styles{
+		Ellipsis:       helpSeparator,
+		ShortKey:       helpKey,
+		ShortDesc:      helpDesc,
+		ShortSeparator: helpSeparator,
+		FullKey:        helpKey,
+		FullDesc:       helpDesc,
+		FullSeparator:  helpSeparator,
+	}
+	return s
+}
+
+// categoryColor returns the foreground color for a category. Theme overrides
+// take precedence over colors defined for the category.
+func (s styles) categoryColor(c Category) lipgloss.TerminalColor {
+	if color, ok := s.theme.Categories[c.ID]; ok {
+		return color.adaptive()
+	}
+	if !s.theme.CategoryColors {
+		return lipgloss.NoColor{}
+	}
+	return c.ForegroundColor()
+}
@@ -0,0 +1,119 @@
+package myhours
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxListedTasks is the number of tasks listed in the timer view. Each listed
+// task can be started with a number key, so this should not exceed 9.
+const maxListedTasks = 9
+
+// listTasks returns the tasks to list: pinned tasks first, followed by recent
+// tasks that are not pinned. At most limit tasks are returned.
+func listTasks(pinned, recent []Task, limit int) []Task {
+	tasks := slices.Clone(pinned)
+	for _, task := range recent {
+		if !slices.Contains(tasks, task) {
+			tasks = append(tasks, task)
+		}
+	}
+	if len(tasks) > limit {
+		tasks = tasks[:limit]
+	}
+	return tasks
+}
+
+// tasks returns the tasks listed in the timer view.
+func (m MyHours) tasks() []Task {
+	return listTasks(m.settings.PinnedTasks, m.state.recentTasks, maxListedTasks)
+}
+
+// loadRecentTasks returns a command that loads the latest distinct tasks.
+func (m MyHours) loadRecentTasks() tea.Cmd {
+	return func() tea.Msg {
+		tasks, err := m.db.RecentTasks(m.ctx, maxListedTasks)
+		if err != nil {
+			m.l.Error("failed to load recent tasks", slog.String("error", err.Error()))
+			return errorMsg{err: fmt.Errorf("loading recent tasks failed: %w", err)}
+		}
+		return recentTasksMsg{tasks: tasks}
+	}
+}
+
+// startTask starts a new record for the listed task selected by given number
+// key. A running record is switched to the task.
+func (m *MyHours) startTask(number string) tea.Cmd {
+	n, err := strconv.Atoi(number)
+	tasks := m.tasks()
+	if err != nil || n < 1 || n > len(tasks) {
+		return nil
+	}
+	task := tasks[n-1]
+	record := m.state.activeRecord
+	if record.Active() {
+		if record.CategoryID == task.CategoryID && record.Notes == task.Notes {
+			return m.setStatus("already tracking this task", false)
+		}
+		return m.switchRecord(m.now(), task.CategoryID, task.Notes)
+	}
+	m.setActiveRecord(Record{CategoryID: task.CategoryID, Notes: task.Notes})
+	return m.timer.start()
+}
+
+// togglePinnedTask pins the task of the active record, or unpins it if already
+// pinned.
+func (m *MyHours) togglePinnedTask() tea.Cmd {
+	task := Task{CategoryID: m.state.activeRecord.CategoryID, Notes: m.state.activeRecord.Notes}
+	pinned := slices.Clone(m.settings.PinnedTasks)
+	if i := slices.Index(pinned, task); i >= 0 {
+		pinned = slices.Delete(pinned, i, i+1)
+	} else {
+		pinned = append(pinned, task)
+	}
+	value, err := json.Marshal(pinned)
+	if err != nil {
+		return func() tea.Msg {
+			return errorMsg{err: fmt.Errorf("json.Marshal: %w", err)}
+		}
+	}
+	return m.updateSetting(SettingPinnedTasks, string(value), func(s *Settings) {
+		s.PinnedTasks = pinned
+	})
+}
+
+// renderTasks renders the list of pinned and recent tasks, numbered for
+// starting them quickly.
+func (m MyHours) renderTasks(width int) string {
+	tasks := m.tasks()
+	if len(tasks) == 0 {
+		return ""
+	}
+	var doc strings.Builder
+	doc.WriteString(m.styles.timerLabel.Render("Tasks:"))
+	doc.WriteString("\n")
+	for i, task := range tasks {
+		cat := findCategory(m.categories, task.CategoryID)
+		doc.WriteString(strconv.Itoa(i + 1))
+		doc.WriteString(" ")
+		if slices.Contains(m.settings.PinnedTasks, task) {
+			doc.WriteString(m.styles.glyphs.pinned)
+		} else {
+			doc.WriteString(strings.Repeat(" ", lipgloss.Width(m.styles.glyphs.pinned)))
+		}
+		doc.WriteString(lipgloss.NewStyle().Foreground(m.styles.categoryColor(cat)).Render(cat.Name))
+		if task.Notes != "" {
+			doc.WriteString(" ")
+			doc.WriteString(task.Notes)
+		}
+		doc.WriteString("\n")
+	}
+	return lipgloss.NewStyle().Width(width).Padding(0, 1).Render(doc.String()) + "\n"
+}
@@ -0,0 +1,156 @@
+package myhours
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Timebox timing. Length of work phases is chosen by the user, breaks follow
+// the pomodoro technique.
+const (
+	// shortBreakLength is the length of breaks between pomodoros.
+	shortBreakLength = 5 * time.Minute
+	// longBreakLength is the length of breaks after every longBreakInterval
+	// pomodoros.
+	longBreakLength = 15 * time.Minute
+	// longBreakInterval is the number of pomodoros between long breaks.
+	longBreakInterval = 4
+	// flashLength is how long the timer is highlighted after a phase ends.
+	flashLength = 2 * time.Second
+	// defaultTimeboxLength is suggested when setting a timebox.
+	defaultTimeboxLength = 25 * time.Minute
+)
+
+// timeboxPhase identifies the phase of a timebox.
+type timeboxPhase int
+
+const (
+	// phaseOff means no timebox is set.
+	phaseOff timeboxPhase = iota
+	// phaseWork counts down a pomodoro.
+	phaseWork
+	// phaseShortBreak counts down a short break.
+	phaseShortBreak
+	// phaseLongBreak counts down a long break.
+	phaseLongBreak
+)
+
+func (p timeboxPhase) String() string {
+	switch p {
+	case phaseWork:
+		return "work"
+	case phaseShortBreak:
+		return "short break"
+	case phaseLongBreak:
+		return "long break"
+	default:
+		return "off"
+	}
+}
+
+// timebox counts down pomodoros, and the breaks between them.
+type timebox struct {
+	phase timeboxPhase
+	// work is the length of work phases.
+	work time.Duration
+	// started is the starting time of current phase.
+	started time.Time
+	// length of the current phase.
+	length time.Duration
+	// completed is the number of pomodoros completed since timebox was set.
+	completed int
+	// flashUntil is the time until which the timer should be highlighted.
+	flashUntil time.Time
+}
+
+// newTimebox returns a timebox with a work phase of given length starting at
+// given time.
+func newTimebox(work time.Duration, now time.Time) timebox {
+	return timebox{phase: phaseWork, work: work, started: now, length: work}
+}
+
+// remaining returns the time left in current phase.
+func (b timebox) remaining(now time.Time) time.Duration {
+	return max(0, b.length-now.Sub(b.started))
+}
+
+// progress returns the completed portion of current phase, from 0 to 1.
+func (b timebox) progress(now time.Time) float64 {
+	if b.length <= 0 {
+		return 0
+	}
+	return min(1, float64(now.Sub(b.started))/float64(b.length))
+}
+
+// expired returns true if the current phase has ended.
+func (b timebox) expired(now time.Time) bool {
+	return b.phase != phaseOff && !now.Before(b.started.Add(b.length))
+}
+
+// flashing returns true if the timer should be highlighted.
+func (b timebox) flashing(now time.Time) bool {
+	return now.Before(b.flashUntil)
+}
+
+// next returns the timebox moved to the next phase, starting at given time.
+// Completing a work phase starts a break, and completing a break starts work.
+func (b timebox) next(now time.Time) timebox {
+	switch b.phase {
+	case phaseOff:
+		return b
+	case phaseWork:
+		b.completed++
+		if b.completed%longBreakInterval == 0 {
+			b.phase, b.length = phaseLongBreak, longBreakLength
+		} else {
+			b.phase, b.length = phaseShortBreak, shortBreakLength
+		}
+	default:
+		b.phase, b.length = phaseWork, b.work
+	}
+	b.started = now
+	b.flashUntil = now.Add(flashLength)
+	return b
+}
+
+// advanceTimebox moves the timebox into the next phase. Finished pomodoros are
+// stored for the active record, and breaks pause the record.
+func (m *MyHours) advanceTimebox(now time.Time) tea.Cmd {
+	prev := m.state.timebox.phase
+	m.state.timebox = m.state.timebox.next(now)
+	commands := []tea.Cmd{ringBell}
+	record := m.state.activeRecord
+	if prev == phaseWork {
+		record.Pomodoros++
+		m.setActiveRecord(record)
+		commands = append(commands,
+			m.writeEvent(newRecordEvent(eventPomodoro, record, now)),
+			m.setStatus("Pomodoro done, time for a "+m.state.timebox.phase.String(), false),
+		)
+		if !record.Paused() {
+			commands = append(commands, m.timer.pause())
+		}
+		return tea.Batch(commands...)
+	}
+	commands = append(commands, m.setStatus("Break is over, back to work", false))
+	if record.Paused() {
+		commands = append(commands, m.timer.resume())
+	}
+	return tea.Batch(commands...)
+}
+
+// ringBell is a command that rings the terminal bell. The renderer only
+// writes changed lines of the view, so the bell goes to the terminal directly.
+func ringBell() tea.Msg {
+	_, _ = os.Stdout.WriteString("\a")
+	return nil
+}
+
+// progressBar renders a bar of given width, filled up to percent.
+func progressBar(width int, percent float64) string {
+	filled := int(float64(width) * min(1, max(0, percent)))
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
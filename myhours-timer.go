@@ -6,17 +6,29 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
-func newTimer(interval time.Duration) timer {
-	return timer{interval: interval}
+// timerJumpThreshold is the minimum wall clock jump between ticks that is
+// reported with timerJumpMsg. Ticks are frequent, so a jump this large means the
+// program was not running, for example because the computer was suspended.
+const timerJumpThreshold = time.Minute
+
+func newTimer(interval time.Duration, clock Clock) timer {
+	return timer{interval: interval, clock: clock}
 }
 
 // timer provides a very simple timer for the application.
 type timer struct {
 	interval time.Duration
+	clock    Clock
 	t0       time.Time
 	t1       time.Time
 	tag      int
 	running  bool
+	// lastTick is the wall clock time of the previous tick.
+	lastTick time.Time
+	// breaks is the total time of finished breaks.
+	breaks time.Duration
+	// pausedAt is the time when the current break started. Zero if not paused.
+	pausedAt time.Time
 }
 
 // update timer model based on messages.
@@ -27,16 +39,38 @@ func (m timer) update(message tea.Msg) (timer, tea.Cmd) {
 			return m, nil
 		}
 		m.t0 = msg.from.Truncate(time.Second)
+		m.breaks = msg.breaks
+		m.pausedAt = msg.pausedAt
 		m.running = true
+		m.lastTick = m.clock.Now()
+		return m, nil
+	case timerPauseMsg:
+		if !m.running || m.paused() {
+			return m, nil
+		}
+		m.pausedAt = msg.at
+		return m, nil
+	case timerResumeMsg:
+		if !m.paused() {
+			return m, nil
+		}
+		m.breaks += msg.at.Sub(m.pausedAt)
+		m.pausedAt = time.Time{}
 		return m, nil
 	case timerStopMsg:
-		// register final tick.
+		// register final tick. A break that is on ends with the timer.
 		m.t1 = msg.end
+		if m.paused() {
+			m.breaks += msg.end.Sub(m.pausedAt)
+			m.pausedAt = time.Time{}
+		}
 		m.running = false
 		return m, nil
 	case timerResetMsg:
 		m.t0 = time.Time{}
 		m.t1 = m.t0
+		m.breaks = 0
+		m.pausedAt = time.Time{}
 		m.running = false
 	case timerTickMsg:
 		if !m.running {
@@ -49,41 +83,128 @@ func (m timer) update(message tea.Msg) (timer, tea.Cmd) {
 			return m, nil
 		}
 		m.tag++
+		// compare wall clock readings, monotonic clock does not advance while
+		// the computer is suspended.
+		now, last := m.clock.Now().Round(0), m.lastTick.Round(0)
+		m.lastTick = now
+		if !last.IsZero() && now.Sub(last) > timerJumpThreshold {
+			return m, tea.Batch(timerTick(m.tag, m.interval), func() tea.Msg {
+				return timerJumpMsg{from: last, to: now}
+			})
+		}
 		return m, timerTick(m.tag, m.interval)
 	}
 	return m, nil
 }
 
-// view of the timer component.
-func (m timer) view() string {
+// restart the timer from given time, regardless of the timer state.
+func (m timer) restart(from time.Time) (timer, tea.Cmd) {
+	m.t0 = from.Truncate(time.Second)
+	m.t1 = time.Time{}
+	m.breaks = 0
+	m.pausedAt = time.Time{}
+	m.running = true
+	m.lastTick = m.clock.Now()
+	m.tag++
+	return m, timerTick(m.tag, m.interval)
+}
+
+// view of the timer component, decorated with the given glyphs.
+func (m timer) view(g glyphs) string {
+	elapsed := m.elapsed(m.clock.Now()).Truncate(time.Second).String()
 	switch {
+	case m.paused():
+		return g.paused + elapsed
 	case m.running:
-		return "🕒 " + time.Since(m.t0).Truncate(time.Second).String()
+		return g.running + elapsed
 	case m.t0.IsZero():
-		return "😴 Idle..."
+		return g.idle + "Idle..."
 	default:
-		return "✅ " + m.t1.Sub(m.t0).Truncate(time.Second).String()
+		return g.done + elapsed
+	}
+}
+
+// elapsed returns the time counted by the timer at given time, excluding breaks.
+func (m timer) elapsed(now time.Time) time.Duration {
+	switch {
+	case m.t0.IsZero():
+		return 0
+	case m.paused():
+		now = m.pausedAt
+	case !m.running:
+		now = m.t1
+	}
+	return now.Sub(m.t0) - m.breaks
+}
+
+// breakTime returns the total time spent on breaks at given time.
+func (m timer) breakTime(now time.Time) time.Duration {
+	if m.paused() {
+		return m.breaks + now.Sub(m.pausedAt)
 	}
+	return m.breaks
+}
+
+// paused returns if the timer is running, but a break is on.
+func (m timer) paused() bool {
+	return !m.pausedAt.IsZero()
 }
 
-// startFrom sets the starting time for the timer to given time.Time.
-func (m timer) startFrom(t time.Time) tea.Cmd {
+// startFrom starts the timer from the starting time of given record. Breaks of
+// the record are taken into account.
+func (m timer) startFrom(record Record) tea.Cmd {
+	msg := timerStartMsg{from: record.Start}
+	for _, b := range record.Breaks {
+		if !b.End.IsZero() {
+			msg.breaks += b.End.Sub(b.Start)
+		}
+	}
+	if record.Paused() {
+		msg.pausedAt = record.Breaks[len(record.Breaks)-1].Start
+	}
 	return tea.Sequence(func() tea.Msg {
-		return timerStartMsg{from: t}
+		return msg
 	}, timerTick(m.tag, m.interval))
 }
 
+// pause starts a break, counting from now.
+func (m timer) pause() tea.Cmd {
+	return func() tea.Msg {
+		return timerPauseMsg{at: m.clock.Now()}
+	}
+}
+
+// resume ends a break, counting from now.
+func (m timer) resume() tea.Cmd {
+	return func() tea.Msg {
+		return timerResumeMsg{at: m.clock.Now()}
+	}
+}
+
 // start starts the timer, counting from now.
 func (m timer) start() tea.Cmd {
+	return m.startAt(m.clock.Now())
+}
+
+// startAt starts the timer, counting from given time. Used for starting the
+// timer retroactively.
+func (m timer) startAt(at time.Time) tea.Cmd {
 	return tea.Sequence(func() tea.Msg {
-		return timerStartMsg{from: time.Now()}
+		return timerStartMsg{from: at}
 	}, timerTick(m.tag, m.interval))
 }
 
 // stop stops the timer.
 func (m timer) stop() tea.Cmd {
+	return m.stopAt(m.clock.Now())
+}
+
+// stopAt stops the timer at given time. Used for stopping the timer
+// retroactively.
+func (m timer) stopAt(at time.Time) tea.Cmd {
+	start := m.t0
 	return func() tea.Msg {
-		return timerStopMsg{m.t0, time.Now()}
+		return timerStopMsg{start: start, end: at}
 	}
 }
 
@@ -0,0 +1,133 @@
+package myhours
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// totals keeps the records of the current week for showing the time worked
+// today and this week. Records are loaded when they change, or when the day
+// changes. Time of the active record is added on top when rendering, so the
+// totals stay live without querying the database on every tick.
+type totals struct {
+	// day is the start of the day the records were loaded on.
+	day time.Time
+	// week is the start of the week the records cover.
+	week time.Time
+	// records of the week, finished ones only.
+	records []Record
+	// loading is set while records are being loaded.
+	loading bool
+}
+
+// totalsRow is the time spent on a category today and this week.
+type totalsRow struct {
+	categoryID int64
+	day        time.Duration
+	week       time.Duration
+}
+
+// stale returns true if totals were loaded on some other day than the day of
+// now, and are not being loaded right now.
+func (t totals) stale(now time.Time) bool {
+	day, _ := reportDatesDaily(now, 0)
+	return !t.loading && !t.day.Equal(day)
+}
+
+// sum returns time spent per category and overall, with the time of the active
+// record counted up to now. Records are placed on days by their start time, as
+// in reports. Categories are listed in given order, skipping those without
+// time spent this week.
+func (t totals) sum(categories []Category, active Record, elapsed time.Duration) ([]totalsRow, totalsRow) {
+	var (
+		byCategory = make(map[int64]*totalsRow)
+		total      totalsRow
+		dayEnd     = t.day.AddDate(0, 0, 1)
+	)
+	add := func(categoryID int64, start time.Time, d time.Duration) {
+		if start.Before(t.week) || !start.Before(t.week.AddDate(0, 0, 7)) {
+			return
+		}
+		row, ok := byCategory[categoryID]
+		if !ok {
+			row = &totalsRow{categoryID: categoryID}
+			byCategory[categoryID] = row
+		}
+		row.week += d
+		total.week += d
+		if !start.Before(t.day) && start.Before(dayEnd) {
+			row.day += d
+			total.day += d
+		}
+	}
+	for _, record := range t.records {
+		// the active record is counted from the timer instead.
+		if active.ID > 0 && record.ID == active.ID {
+			continue
+		}
+		add(record.CategoryID, record.Start, record.Duration())
+	}
+	if !active.Start.IsZero() {
+		add(active.CategoryID, active.Start, elapsed)
+	}
+	var rows []totalsRow
+	for _, cat := range categories {
+		if row, ok := byCategory[cat.ID]; ok {
+			rows = append(rows, *row)
+			delete(byCategory, cat.ID)
+		}
+	}
+	// records may refer to categories that are no longer listed.
+	var unlisted []totalsRow
+	for _, row := range byCategory {
+		unlisted = append(unlisted, *row)
+	}
+	slices.SortFunc(unlisted, func(a, b totalsRow) int { return cmp.Compare(a.categoryID, b.categoryID) })
+	return append(rows, unlisted...), total
+}
+
+// loadTotals returns a command that loads the records of the current week.
+func (m *MyHours) loadTotals() tea.Cmd {
+	m.state.totals.loading = true
+	day, _ := reportDatesDaily(m.now(), 0)
+	from, before := reportDatesWeekly(m.now(), 0)
+	return func() tea.Msg {
+		records, err := m.db.Records(m.ctx, from, before)
+		if err != nil {
+			m.l.Error("failed to load totals", slog.String("error", err.Error()))
+			return totalsMsg{day: day, week: from, err: fmt.Errorf("loading totals failed: %w", err)}
+		}
+		return totalsMsg{day: day, week: from, records: records}
+	}
+}
+
+// renderTotals renders the time spent today and this week, per category and
+// overall.
+func (m MyHours) renderTotals() string {
+	rows, total := m.state.totals.sum(m.categories, m.state.activeRecord, m.timer.elapsed(m.now()))
+	column := lipgloss.NewStyle().Width(12)
+	var doc strings.Builder
+	doc.WriteString(m.styles.timerLabel.Render("Totals:"))
+	doc.WriteString(column.Render("Today"))
+	doc.WriteString(column.Render("This week"))
+	doc.WriteString("\n")
+	for _, row := range rows {
+		cat := findCategory(m.categories, row.categoryID)
+		doc.WriteString(m.styles.timerLabel.MaxHeight(1).Bold(false).Foreground(m.styles.categoryColor(cat)).Render(cat.Name))
+		doc.WriteString(column.Render(shortDuration(row.day.Truncate(time.Second))))
+		doc.WriteString(column.Render(shortDuration(row.week.Truncate(time.Second))))
+		doc.WriteString("\n")
+	}
+	doc.WriteString(m.styles.timerLabel.Render("Total"))
+	doc.WriteString(column.Render(shortDuration(total.day.Truncate(time.Second))))
+	doc.WriteString(column.Render(shortDuration(total.week.Truncate(time.Second))))
+	doc.WriteString("\n")
+	return doc.String()
+}
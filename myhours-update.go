@@ -1,7 +1,12 @@
 package myhours
 
 import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
 	"strconv"
 	"time"
 
@@ -9,6 +14,16 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+const (
+	// writeBackoff is the wait before the first retry of pending writes. The
+	// wait doubles with each retry, up to maxWriteBackoff, until a write
+	// succeeds.
+	writeBackoff    = time.Second
+	maxWriteBackoff = 5 * time.Minute
+	// statusTimeout is how long status notifications are shown.
+	statusTimeout = 5 * time.Second
+)
+
 // Update model state based on the incoming message.
 //
 // Returns the updated model (MyHours) and command that needs to be executed
@@ -30,8 +45,23 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 	var commands []tea.Cmd
 	switch msg := message.(type) {
 	case reportDataMsg:
-		// new report data is ready. Let's see if we still needed, and if we do,
-		// store it to state.
+		// new report data is ready. Cache it for paging back and forth, unless
+		// records have changed while it was loading.
+		key := reportKey{viewID: msg.viewID, pageNo: msg.pageNo, categoryID: msg.categoryID}
+		if msg.err == nil {
+			m.state.reportCache.put(msg.generation, key, reportCacheEntry{
+				from:  msg.from,
+				opts:  msg.opts,
+				table: reportTable{title: msg.title, headers: msg.headers, rows: msg.rows, style: msg.style},
+			})
+		}
+		if msg.prefetch {
+			delete(m.state.reportCache.pending, key)
+			return m, nil
+		}
+		// Loads are cancelled when view, page or category changes, but one may
+		// have completed just before that. Let's see if we still need it, and
+		// if we do, store it to state.
 		if m.state.activeView != msg.viewID {
 			// view changed already. Not relevant anymore.
 			return m, nil
@@ -44,11 +74,166 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 			// category changed already. Not relevant anymore
 			return m, nil
 		}
-		m.state.reportRows = msg.rows
-		m.state.reportHeaders = msg.headers
-		m.state.reportTitle = msg.title
-		m.state.reportStyle = msg.style
-		m.state.reportLoading = false
+		m.showReport(reportTable{title: msg.title, headers: msg.headers, rows: msg.rows, style: msg.style})
+		if msg.err != nil {
+			commands = append(commands, m.setStatus(msg.err.Error(), true))
+		}
+		// page is in view, get the pages next to it ready.
+		commands = append(commands, m.prefetchReports())
+	case errorMsg:
+		commands = append(commands, m.setStatus(msg.err.Error(), true))
+	case statusMsg:
+		commands = append(commands, m.setStatus(msg.text, false))
+	case clearStatusMsg:
+		// only clear if the status hasn't been replaced since.
+		if msg.id == m.state.statusID {
+			m.state.statusText = ""
+			m.state.statusError = false
+		}
+	case writeFailedMsg:
+		m.doneWriting(msg.record)
+		if !errors.Is(msg.err, ErrTransient) && !m.backsTimer(msg.record) {
+			// retrying won't help. Changes waiting for the record are lost with
+			// it, unless it was changed while writing.
+			if cmd := m.writeQueued(msg.record); cmd != nil {
+				commands = append(commands, cmd)
+			} else {
+				m.dropEvents(msg.record)
+			}
+			commands = append(commands, m.setStatus("saving failed: "+msg.err.Error(), true))
+			break
+		}
+		// storing record failed. Queue it for later, and carry on with the record
+		// as if it had been stored. The timer keeps running regardless, so the
+		// record backing it is kept even if retrying may not help. A newer
+		// version queued while writing takes its place.
+		if !m.queued(msg.record) {
+			m.state.pendingWrites = queueWrite(m.state.pendingWrites, msg.record)
+			if sameRecord(m.state.activeRecord, msg.record) {
+				m.setActiveRecord(msg.record)
+			}
+		}
+		commands = append(commands, m.scheduleRetry(), m.setStatus("saving failed, will retry: "+msg.err.Error(), true))
+	case retryWritesMsg:
+		m.state.retrying = false
+		// events of records without an ID wait for the record to be stored.
+		var events, waiting []recordEvent
+		for _, event := range m.state.pendingEvents {
+			if event.recordID > 0 {
+				events = append(events, event)
+			} else {
+				waiting = append(waiting, event)
+			}
+		}
+		// records with a write in flight wait for it to complete.
+		var records, writing []Record
+		for _, record := range m.state.pendingWrites {
+			if m.isWriting(record) {
+				writing = append(writing, record)
+			} else {
+				records = append(records, record)
+			}
+		}
+		// settings with a write in flight wait for it as well.
+		var settings, writingSettings []settingWrite
+		for _, write := range m.state.pendingSettings {
+			if slices.Contains(m.state.writingSettings, write.key) {
+				writingSettings = append(writingSettings, write)
+			} else {
+				settings = append(settings, write)
+			}
+		}
+		if len(records) > 0 || len(events) > 0 {
+			commands = append(commands, m.flushWrites(records, events))
+			m.state.writing = append(m.state.writing, records...)
+			m.state.pendingWrites, m.state.pendingEvents = writing, waiting
+		}
+		if len(settings) > 0 {
+			commands = append(commands, m.flushSettings(settings, nil))
+			m.state.pendingSettings = writingSettings
+		}
+	case pendingWritesMsg:
+		// pending writes were retried. Records that got inserted now have an ID,
+		// so pass that on to the active record if needed, and store the events
+		// that waited for the ID.
+		if len(msg.stored) > 0 {
+			m.state.reportCache.invalidate()
+		}
+		if len(msg.stored) > 0 || msg.err == nil {
+			m.state.retryDelay = 0
+		}
+		for _, record := range msg.stored {
+			m.doneWriting(record)
+			if m.state.activeRecord.ID == 0 && m.state.activeRecord.Start.Equal(record.Start) {
+				m.state.activeRecord.ID = record.ID
+			}
+			commands = append(commands, m.writeQueued(record), m.storeEvents(record))
+		}
+		for _, record := range msg.failed {
+			m.doneWriting(record)
+		}
+		for _, record := range msg.dropped {
+			m.doneWriting(record)
+			if m.backsTimer(record) {
+				// the running timer is never dropped.
+				msg.failed = append(msg.failed, record)
+				continue
+			}
+			m.dropEvents(record)
+		}
+		// newer versions of records may have been queued while retrying, those
+		// take precedence over the failed ones. Failed events go before the ones
+		// queued meanwhile, to keep them in order.
+		for _, record := range msg.failed {
+			if !slices.ContainsFunc(m.state.pendingWrites, func(r Record) bool { return sameRecord(r, record) }) {
+				m.state.pendingWrites = append(m.state.pendingWrites, record)
+			}
+		}
+		m.state.pendingEvents = slices.Concat(msg.failedEvents, m.state.pendingEvents)
+		if m.unsaved() > 0 {
+			commands = append(commands, m.scheduleRetry())
+		}
+		switch {
+		case msg.droppedErr != nil:
+			commands = append(commands, m.setStatus("saving failed: "+msg.droppedErr.Error(), true))
+		case msg.err != nil:
+			commands = append(commands, m.setStatus("saving failed, will retry: "+msg.err.Error(), true))
+		case len(msg.stored) > 0 && len(m.state.pendingWrites) == 0:
+			commands = append(commands, m.setStatus("pending changes saved", false))
+		}
+	case settingsWrittenMsg:
+		// settings were written. Settings changed while being written are
+		// written next, the result of this write is not applied over them.
+		var newer bool
+		for _, write := range slices.Concat(msg.stored, msg.failed, msg.dropped) {
+			m.state.writingSettings = slices.DeleteFunc(m.state.writingSettings, func(key Setting) bool { return key == write.key })
+			newer = newer || slices.ContainsFunc(m.state.pendingSettings, func(w settingWrite) bool { return w.key == write.key })
+		}
+		if len(msg.stored) > 0 {
+			m.state.retryDelay = 0
+		}
+		// like records, settings that failed to be stored are applied as if they
+		// had been stored, and retried later.
+		if settings := msg.settings; settings != nil && len(msg.dropped) == 0 && !newer {
+			commands = append(commands, func() tea.Msg { return updateSettingsMsg{settings: *settings} })
+		}
+		for _, write := range slices.Concat(msg.stored, msg.dropped) {
+			commands = append(commands, m.writeQueuedSetting(write.key))
+		}
+		for _, write := range msg.failed {
+			if !slices.ContainsFunc(m.state.pendingSettings, func(w settingWrite) bool { return w.key == write.key }) {
+				m.state.pendingSettings = append(m.state.pendingSettings, write)
+			}
+		}
+		if m.unsaved() > 0 {
+			commands = append(commands, m.scheduleRetry())
+		}
+		switch {
+		case msg.droppedErr != nil:
+			commands = append(commands, m.setStatus(msg.droppedErr.Error(), true))
+		case msg.err != nil:
+			commands = append(commands, m.setStatus("saving failed, will retry: "+msg.err.Error(), true))
+		}
 	case initTimerMsg:
 		// timer has been initialized. If init contains details for a record, set
 		// that record as the active one and start the timer running from record
@@ -60,7 +245,7 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// if the record is supposed to be active, we must start timer.
 		if m.state.activeRecord.Active() {
-			commands = append(commands, m.timer.startFrom(m.state.activeRecord.Start))
+			commands = append(commands, m.timer.startFrom(m.state.activeRecord))
 		}
 		// enable keys for default view now that everything should be ready.
 		m.keys.openHelp.SetEnabled(true)
@@ -70,8 +255,47 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 		m.keys.switchTaskCategory.SetEnabled(true)
 		m.keys.stopRecord.SetEnabled(m.state.activeRecord.Active())
 		m.keys.startRecord.SetEnabled(!m.state.activeRecord.Active())
+		m.keys.stopRecordAt.SetEnabled(m.state.activeRecord.Active())
+		m.keys.startRecordAt.SetEnabled(!m.state.activeRecord.Active())
 		m.keys.newRecord.SetEnabled(!m.state.activeRecord.Active())
+		m.keys.switchRecord.SetEnabled(m.state.activeRecord.Active())
+		m.keys.pauseRecord.SetEnabled(m.state.activeRecord.Active() && !m.state.activeRecord.Paused())
+		m.keys.resumeRecord.SetEnabled(m.state.activeRecord.Paused())
+		m.keys.timebox.SetEnabled(m.state.activeRecord.Active())
+		m.keys.startTask.SetEnabled(true)
+		m.keys.pinTask.SetEnabled(true)
+		m.keys.palette.SetEnabled(true)
 		m.state.ready = true
+		commands = append(commands, m.loadTotals(), m.loadDashboard())
+		// a restored timer may have been left running by accident.
+		m.checkForgotten()
+	case lastRecordMsg:
+		// previous record was loaded for validating a retroactive start.
+		p := &m.state.prompt
+		switch {
+		case p.kind != promptStartAt:
+		case msg.err != nil:
+			// the start time can't be checked, the prompt stays blocked
+			// until cancelled.
+			p.err = msg.err.Error()
+		default:
+			p.loading = false
+			if msg.record.End.After(p.notBefore) {
+				p.notBefore = msg.record.End
+			}
+		}
+	case timerJumpMsg:
+		// computer was likely suspended, the timer may have been forgotten running.
+		m.l.Debug("wall clock jump detected", slog.Time("from", msg.from), slog.Time("to", msg.to))
+		m.checkForgotten()
+	case recordSwitchedMsg:
+		// active record was ended and a new one started to continue from it.
+		m.setActiveRecord(msg.started)
+		m.state.reportCache.invalidate()
+		var cmd tea.Cmd
+		m.timer, cmd = m.timer.restart(msg.started.Start)
+		cat := findCategory(m.categories, msg.started.CategoryID)
+		commands = append(commands, cmd, m.loadRecentTasks(), m.loadTotals(), m.loadDashboard(), m.setStatus("switched to "+cat.Name+" at "+msg.started.Start.Format(time.TimeOnly), false))
 	case updateCategoriesMsg:
 		// details for available categories has changed. This pretty much happens
 		// only at app init (for now)
@@ -89,21 +313,78 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// we must request update of report data, since category affects what is
 		// show in the tables. If there's a need to load new stuff, cmd is non-nil.
-		if cmd := m.updateReportData(); cmd != nil {
+		commands = append(commands, m.updateReportData())
+		if cmd := m.loadDashboard(); cmd != nil {
 			commands = append(commands, cmd)
 		}
 	case updateRecordMsg:
-		// Record status had been updated.
-		m.state.activeRecord = msg.record
-		// while record is active, can't start new one.
-		m.keys.newRecord.SetEnabled(!m.state.activeRecord.Active())
-		m.keys.startRecord.SetEnabled(!m.state.activeRecord.Active())
-		m.keys.stopRecord.SetEnabled(m.state.activeRecord.Active())
+		// Record status had been updated. Recent tasks and reports may have
+		// changed with it. Events waiting for the record to be stored can be
+		// stored now. If the record was changed while it was being written, the
+		// change is written next, and only the ID of this version is of use.
+		m.doneWriting(msg.record)
+		if cmd := m.writeQueued(msg.record); cmd != nil {
+			if m.state.activeRecord.ID == 0 && m.state.activeRecord.Start.Equal(msg.record.Start) {
+				m.state.activeRecord.ID = msg.record.ID
+			}
+			commands = append(commands, cmd)
+		} else {
+			m.setActiveRecord(msg.record)
+		}
+		m.state.reportCache.invalidate()
+		m.state.retryDelay = 0
+		commands = append(commands, m.storeEvents(msg.record))
+		commands = append(commands, m.loadRecentTasks(), m.loadTotals(), m.loadDashboard())
+	case dashboardDataMsg:
+		if msg.categoryID != m.settings.DefaultCategoryID {
+			// category changed already. Not relevant anymore
+			return m, nil
+		}
+		m.state.dashboard.day = msg.day
+		m.state.dashboard.week = msg.week
+		m.state.dashboard.loaded = msg.err == nil
+		if msg.err != nil {
+			commands = append(commands, m.setStatus(msg.err.Error(), true))
+		}
+	case totalsMsg:
+		m.state.totals = totals{day: msg.day, week: msg.week, records: msg.records}
+		if msg.err != nil {
+			commands = append(commands, m.setStatus(msg.err.Error(), true))
+		}
+	case recentTasksMsg:
+		m.state.recentTasks = msg.tasks
+	case actionMsg:
+		// command palette runs key actions by pressing their keys, so that they
+		// work just like from the keyboard.
+		binding, ok := m.keys.actions()[msg.action]
+		if !ok || !binding.Enabled() {
+			commands = append(commands, m.setStatus(msg.action+" is not available now", true))
+			break
+		}
+		return m.Update(keyMsg(cmp.Or(msg.key, binding.Keys()[0])))
+	case gotoReportMsg:
+		if cmd := m.gotoReport(msg.viewID, msg.pageNo); cmd != nil {
+			commands = append(commands, cmd)
+		}
+	case exportReportMsg:
+		if _, ok := reportForView(m.state.activeView); !ok || m.state.reportLoading {
+			commands = append(commands, m.setStatus("no report to export", true))
+			break
+		}
+		commands = append(commands, m.exportReport(msg.path))
+	case setNotesMsg:
+		if !m.state.activeRecord.Active() {
+			commands = append(commands, m.setStatus("no active record", true))
+			break
+		}
+		record := m.state.activeRecord
+		record.Notes = msg.notes
+		commands = append(commands, m.updateRecord(record))
 	case timerStartMsg:
 		// timer has started. start a new record in database with the starting
 		// timestamp of the timer. But only allow it when the task has no ID yet.
 		if m.state.activeRecord.ID == 0 {
-			commands = append(commands, m.startNewRecord(msg.from, m.state.activeRecord.CategoryID))
+			commands = append(commands, m.startNewRecord(msg.from.Truncate(time.Second), m.state.activeRecord.CategoryID, m.state.activeRecord.Notes))
 		} else {
 			record := m.state.activeRecord
 			record.End = time.Time{}
@@ -116,8 +397,40 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 			record := m.state.activeRecord
 			record.Start = msg.start
 			record.End = msg.end
+			record.Breaks = endBreaks(record.Breaks, msg.end)
 			commands = append(commands, m.updateRecord(record))
 		}
+		m.state.timebox = timebox{}
+	case timerPauseMsg:
+		// break started. Keep track of it in the active record, and store it.
+		if m.state.activeRecord.Active() && !m.state.activeRecord.Paused() {
+			record := m.state.activeRecord
+			record.Breaks = append(slices.Clone(record.Breaks), Break{Start: msg.at})
+			m.setActiveRecord(record)
+			commands = append(commands, m.writeEvent(newRecordEvent(eventPause, record, msg.at)))
+		}
+	case timerResumeMsg:
+		// break ended.
+		if m.state.activeRecord.Paused() {
+			record := m.state.activeRecord
+			record.Breaks = endBreaks(record.Breaks, msg.at)
+			m.setActiveRecord(record)
+			commands = append(commands, m.writeEvent(newRecordEvent(eventResume, record, msg.at)))
+		}
+		// resuming in the middle of a timebox break gets back to work early.
+		if b := m.state.timebox; b.phase == phaseShortBreak || b.phase == phaseLongBreak {
+			m.state.timebox = newTimebox(b.work, msg.at)
+			m.state.timebox.completed = b.completed
+		}
+	case timerTickMsg:
+		// timebox phases end on timer ticks.
+		if now := m.now(); m.state.timebox.expired(now) {
+			commands = append(commands, m.advanceTimebox(now))
+		}
+		// totals are per day, reload them once the day changes.
+		if m.state.ready && m.state.totals.stale(m.now()) {
+			commands = append(commands, m.loadTotals(), m.loadDashboard())
+		}
 	case timerResetMsg:
 		// on timer reset, we reset the record as well.
 		record := Record{CategoryID: m.state.activeRecord.CategoryID}
@@ -130,7 +443,21 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 		m.state.viewHeight = msg.Height - styleWindow.GetVerticalFrameSize() - 1
 		m.state.screenWidth = msg.Width
 		m.state.screenHeight = msg.Height
+		// wide terminals show the dashboard in timer view. Load reports for it
+		// when it comes into use.
+		enabled := useDashboard(msg.Width, msg.Height)
+		if enabled && !m.state.dashboard.enabled && m.state.ready {
+			m.state.dashboard.enabled = true
+			commands = append(commands, m.loadDashboard())
+		}
+		m.state.dashboard.enabled = enabled
+		m.scrollReport()
+		m.scrollSettings()
 	case tea.KeyMsg:
+		// open prompt takes all key input, except for forced quit.
+		if m.state.prompt.kind != promptNone && msg.Type != tea.KeyCtrlC {
+			return m.updatePrompt(msg)
+		}
 		// some keypress event has happened. We try to avoid doing direct state
 		// manipulation here and instead just trigger the side effects that we
 		// want. This should keep the update method faster, offloading things like
@@ -169,46 +496,61 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 			// increment page number, up to maximum. This should take care of
 			// seemingly impossible situation where pageNo would be positive non-zero.
 			m.state.reportPage[m.state.activeView] = incMax(pageNo, 0)
+			m.state.reportCursor.row, m.state.reportCursor.offset = 0, 0
 			// request the update of report data.
-			if cmd := m.updateReportData(); cmd != nil {
-				m.state.reportLoading = true
-				commands = append(commands, cmd)
-			}
+			commands = append(commands, m.updateReportData())
 		case key.Matches(msg, m.keys.prevReportPage):
 			// report page change requested. This should trigger re-fetching of
 			// data if page actually changed.
 			// decrement page number by one for the previous page, or use max if
 			// value is somehow positive non-zero (which it should never be)
 			m.state.reportPage[m.state.activeView] = decMax(m.reportPageNo(), 0)
+			m.state.reportCursor.row, m.state.reportCursor.offset = 0, 0
 			// and re-request report data update.
-			if cmd := m.updateReportData(); cmd != nil {
-				m.state.reportLoading = true
-				commands = append(commands, cmd)
-			}
+			commands = append(commands, m.updateReportData())
+		case key.Matches(msg, m.keys.reportRowUp) && m.state.activeView == settingsView:
+			m.state.settingsCursor.row--
+			m.scrollSettings()
+		case key.Matches(msg, m.keys.reportRowDown) && m.state.activeView == settingsView:
+			m.state.settingsCursor.row++
+			m.scrollSettings()
+		case key.Matches(msg, m.keys.reportRowUp):
+			m.state.reportCursor.row--
+			m.scrollReport()
+		case key.Matches(msg, m.keys.reportRowDown):
+			m.state.reportCursor.row++
+			m.scrollReport()
+		case key.Matches(msg, m.keys.editSetting):
+			commands = append(commands, m.openSettingPrompt())
+		case key.Matches(msg, m.keys.sortReport):
+			// cycle through columns, and back to report order.
+			cursor := &m.state.reportCursor
+			cursor.sortBy = incWrap(cursor.sortBy, 0, len(m.state.reportHeaders))
+			cursor.row, cursor.offset = 0, 0
+		case key.Matches(msg, m.keys.reverseSort):
+			cursor := &m.state.reportCursor
+			cursor.sortDesc = !cursor.sortDesc
+			cursor.row, cursor.offset = 0, 0
 		case key.Matches(msg, m.keys.nextTab):
 			// select next active tab. We allow wrapping back to start.
 			m.state.activeView = incWrap(m.state.activeView, 0, len(m.viewNames)-1)
 			// enable/disable keys for report activities based on if view is
 			// currently a reporting view or not.
-			m.keys.nextReportPage.SetEnabled(m.state.activeView > 0)
-			m.keys.prevReportPage.SetEnabled(m.state.activeView > 0)
+			m.enableViewKeys(m.state.activeView)
+			// reports have different columns, start from the top unsorted.
+			m.state.reportCursor = reportCursor{}
 			// update report data if reporting view changed / came into view.
-			if cmd := m.updateReportData(); cmd != nil {
-				m.state.reportLoading = true
-				commands = append(commands, cmd)
-			}
+			commands = append(commands, m.updateReportData())
 		case key.Matches(msg, m.keys.prevTab):
 			// select previous tab. Allow wrapping straight to last.
 			m.state.activeView = decWrap(m.state.activeView, 0, len(m.viewNames)-1)
 			// enable/disable keys for report activities based on if view is
 			// currently a reporting view or not.
-			m.keys.nextReportPage.SetEnabled(m.state.activeView > 0)
-			m.keys.prevReportPage.SetEnabled(m.state.activeView > 0)
+			m.enableViewKeys(m.state.activeView)
+			// reports have different columns, start from the top unsorted.
+			m.state.reportCursor = reportCursor{}
 			// update report data if reporting view changed / came into view.
-			if cmd := m.updateReportData(); cmd != nil {
-				m.state.reportLoading = true
-				commands = append(commands, cmd)
-			}
+			commands = append(commands, m.updateReportData())
 		case key.Matches(msg, m.keys.startRecord, m.keys.stopRecord):
 			// timer start/stop requested. start or stop based on the current
 			// record status
@@ -216,16 +558,47 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 			case m.state.activeRecord.Active():
 				commands = append(commands, m.timer.stop())
 			case m.state.activeRecord.Finished():
-				commands = append(commands, m.timer.startFrom(m.state.activeRecord.Start))
+				commands = append(commands, m.timer.startFrom(m.state.activeRecord))
 			default:
 				commands = append(commands, m.timer.start())
 			}
+		case key.Matches(msg, m.keys.startRecordAt, m.keys.stopRecordAt):
+			// retroactive start/stop asks for the time first.
+			if m.state.activeRecord.Active() {
+				commands = append(commands, m.openStopAtPrompt())
+			} else {
+				commands = append(commands, m.openStartAtPrompt())
+			}
+		case key.Matches(msg, m.keys.startTask):
+			commands = append(commands, m.startTask(msg.String()))
+		case key.Matches(msg, m.keys.pinTask):
+			commands = append(commands, m.togglePinnedTask())
+		case key.Matches(msg, m.keys.pauseRecord):
+			commands = append(commands, m.timer.pause())
+		case key.Matches(msg, m.keys.resumeRecord):
+			commands = append(commands, m.timer.resume())
+		case key.Matches(msg, m.keys.toggleBreaks):
+			show := !m.settings.ReportShowBreaks
+			commands = append(commands, m.updateSetting(SettingReportShowBreaks, strconv.FormatBool(show), func(s *Settings) {
+				s.ReportShowBreaks = show
+			}))
+		case key.Matches(msg, m.keys.switchRecord):
+			if m.state.activeRecord.Active() {
+				commands = append(commands, m.openSwitchPrompt())
+			}
+		case key.Matches(msg, m.keys.timebox):
+			if m.state.activeRecord.Active() {
+				commands = append(commands, m.openTimeboxPrompt())
+			}
+		case key.Matches(msg, m.keys.palette):
+			commands = append(commands, m.openPalette())
 		case key.Matches(msg, m.keys.newRecord):
 			if !m.state.activeRecord.Active() {
 				commands = append(commands, m.timer.reset())
 			}
 		case key.Matches(msg, m.keys.quit):
 			m.state.quitting = true
+			m.cancel()
 			return m, tea.Quit
 		}
 	}
@@ -235,88 +608,484 @@ func (m MyHours) Update(message tea.Msg) (tea.Model, tea.Cmd) {
 	if m.timer, cmd = m.timer.update(message); cmd != nil {
 		commands = append(commands, cmd)
 	}
+	// prompt text input needs messages for things like cursor blinking. Keys
+	// are passed on by updatePrompt, the key that opened the prompt must not
+	// end up in the input.
+	if _, isKey := message.(tea.KeyMsg); !isKey && m.state.prompt.input != inputNone {
+		if m.state.prompt.field, cmd = m.state.prompt.field.Update(message); cmd != nil {
+			commands = append(commands, cmd)
+		}
+	}
 	// return a batch of changes. This will result in the commands being handled
 	// in asynchronous, non-deterministic order. But that should not be an issue
 	// for us.
 	return m, tea.Batch(commands...)
 }
 
-func (m MyHours) startNewRecord(start time.Time, categoryID int64) tea.Cmd {
+// startNewRecord returns a command that stores a new active record.
+func (m *MyHours) startNewRecord(start time.Time, categoryID int64, notes string) tea.Cmd {
+	return m.updateRecord(Record{Start: start, CategoryID: categoryID, Notes: notes})
+}
+
+// updateRecord returns a command that stores the record. Any pending write of
+// the same record is superseded by this one. If the record is being written
+// already, the record is queued behind that write, and nil is returned. The
+// write in flight may insert the record, so writing it at the same time could
+// insert it twice.
+func (m *MyHours) updateRecord(record Record) tea.Cmd {
+	if m.isWriting(record) {
+		m.state.pendingWrites = queueWrite(m.state.pendingWrites, record)
+		return nil
+	}
+	m.state.pendingWrites = slices.DeleteFunc(m.state.pendingWrites, func(r Record) bool {
+		return sameRecord(r, record)
+	})
+	m.state.writing = append(m.state.writing, record)
 	return func() tea.Msg {
-		id, err := m.db.StartRecord(start, categoryID, "")
+		stored, err := m.storeRecord(record)
 		if err != nil {
-			m.l.Error("failed to store new record", slog.String("error", err.Error()))
-			return tea.Quit()
+			m.l.Error("failed to store record", slog.String("error", err.Error()))
+			return writeFailedMsg{record: record, err: err}
 		}
-		var record *Record
-		if record, err = m.db.Record(id); err != nil {
-			m.l.Error("failed to load record", slog.String("error", err.Error()))
-			return tea.Quit()
+		return updateRecordMsg{record: stored}
+	}
+}
+
+// isWriting returns true if a write of the record is in flight.
+func (m *MyHours) isWriting(record Record) bool {
+	return slices.ContainsFunc(m.state.writing, func(r Record) bool { return sameRecord(r, record) })
+}
+
+// queued returns true if a version of the record is queued for writing.
+func (m *MyHours) queued(record Record) bool {
+	return slices.ContainsFunc(m.state.pendingWrites, func(r Record) bool { return sameRecord(r, record) })
+}
+
+// backsTimer returns true if record is the active record the timer is running
+// for.
+func (m *MyHours) backsTimer(record Record) bool {
+	return record.Active() && sameRecord(m.state.activeRecord, record)
+}
+
+// storedAs returns true if stored is the result of writing record, possibly
+// inserting it.
+func storedAs(record, stored Record) bool {
+	return sameRecord(record, stored) || record.ID == 0 && record.Start.Equal(stored.Start)
+}
+
+// doneWriting marks the write of record as completed. Record may be the
+// version stored, with the ID it got when inserted.
+func (m *MyHours) doneWriting(record Record) {
+	m.state.writing = slices.DeleteFunc(m.state.writing, func(r Record) bool { return storedAs(r, record) })
+}
+
+// writeQueued returns a command that writes the version of the record queued
+// while it was being written, with the ID the record was stored with. Returns
+// nil if there is none.
+func (m *MyHours) writeQueued(stored Record) tea.Cmd {
+	i := slices.IndexFunc(m.state.pendingWrites, func(r Record) bool { return storedAs(r, stored) })
+	if i < 0 {
+		return nil
+	}
+	record := m.state.pendingWrites[i]
+	record.ID = stored.ID
+	m.state.pendingWrites = slices.Delete(m.state.pendingWrites, i, i+1)
+	return m.updateRecord(record)
+}
+
+// storeRecord writes the record into database. Records without an ID are
+// inserted, either as active or finished records depending on the end time.
+// Records that have not been started have nothing to store.
+//
+// Returns the stored record, with ID set.
+func (m MyHours) storeRecord(record Record) (Record, error) {
+	switch {
+	case record.ID > 0:
+		if err := m.db.UpdateRecord(m.ctx, record.ID, record.CategoryID, record.Start, record.End, record.Notes); err != nil {
+			return record, fmt.Errorf("db.UpdateRecord: %w", err)
 		}
-		return updateRecordMsg{record: *record}
+	case record.Start.IsZero():
+		// not started, nothing to store.
+	case record.Active():
+		id, err := m.db.StartRecord(m.ctx, record.Start, record.CategoryID, record.Notes)
+		if err != nil {
+			return record, fmt.Errorf("db.StartRecord: %w", err)
+		}
+		record.ID = id
+	default:
+		ids, err := m.db.ImportRecords(m.ctx, []Record{record})
+		if err != nil {
+			return record, fmt.Errorf("db.ImportRecords: %w", err)
+		}
+		record.ID = ids[0]
 	}
+	return record, nil
 }
 
-func (m MyHours) updateRecord(record Record) tea.Cmd {
+// flushWrites returns a command that tries to store the given pending records,
+// and then the events. Writes are done in order. Writes failing with errors
+// that retrying won't fix are given up on.
+func (m MyHours) flushWrites(records []Record, events []recordEvent) tea.Cmd {
 	return func() tea.Msg {
-		if record.ID > 0 {
-			if err := m.db.UpdateRecord(record.ID, record.CategoryID, record.Start, record.End, record.Notes); err != nil {
-				m.l.Error("failed to update active record category", slog.String("error", err.Error()))
-				return tea.Quit()
+		var res pendingWritesMsg
+		for _, record := range records {
+			stored, err := m.storeRecord(record)
+			switch {
+			case err == nil:
+				res.stored = append(res.stored, stored)
+			case errors.Is(err, ErrTransient):
+				res.failed = append(res.failed, record)
+				res.err = err
+			default:
+				m.l.Error("failed to store record", slog.String("error", err.Error()))
+				res.dropped = append(res.dropped, record)
+				res.droppedErr = err
 			}
 		}
-		return updateRecordMsg{record: record}
+		for i, event := range events {
+			err := m.storeEvent(event)
+			switch {
+			case err == nil:
+			case errors.Is(err, ErrTransient):
+				// later events may depend on this one, they wait as well.
+				res.failedEvents = events[i:]
+				res.err = err
+				return res
+			default:
+				m.l.Error("failed to store record event", slog.String("error", err.Error()))
+				res.droppedErr = err
+			}
+		}
+		return res
+	}
+}
+
+// storeEvent writes the event of a stored record into database.
+func (m MyHours) storeEvent(event recordEvent) error {
+	switch event.kind {
+	case eventPause:
+		if err := m.db.PauseRecord(m.ctx, event.recordID, event.at); err != nil {
+			return fmt.Errorf("storing break failed: %w", err)
+		}
+	case eventResume:
+		if err := m.db.ResumeRecord(m.ctx, event.recordID, event.at); err != nil {
+			return fmt.Errorf("storing break end failed: %w", err)
+		}
+	case eventPomodoro:
+		if err := m.db.CompletePomodoro(m.ctx, event.recordID, event.at); err != nil {
+			return fmt.Errorf("storing pomodoro failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// writeEvent returns a command that stores the event. Events of records that
+// are not stored yet, or that have earlier events pending, are queued behind
+// those instead, and nil is returned.
+func (m *MyHours) writeEvent(event recordEvent) tea.Cmd {
+	if event.recordID == 0 || slices.ContainsFunc(m.state.pendingEvents, func(e recordEvent) bool { return e.recordID == event.recordID }) {
+		m.state.pendingEvents = append(m.state.pendingEvents, event)
+		return nil
+	}
+	return m.flushWrites(nil, []recordEvent{event})
+}
+
+// storeEvents returns a command that stores the events that waited for the
+// record to be stored. Returns nil if there are none.
+func (m *MyHours) storeEvents(record Record) tea.Cmd {
+	if record.ID == 0 {
+		return nil
+	}
+	var events []recordEvent
+	m.state.pendingEvents = slices.DeleteFunc(m.state.pendingEvents, func(e recordEvent) bool {
+		if e.recordID != 0 || !e.of(record) {
+			return false
+		}
+		e.recordID = record.ID
+		events = append(events, e)
+		return true
+	})
+	if len(events) == 0 {
+		return nil
+	}
+	return m.flushWrites(nil, events)
+}
+
+// dropEvents drops the pending events of a record that can't be stored.
+func (m *MyHours) dropEvents(record Record) {
+	m.state.pendingEvents = slices.DeleteFunc(m.state.pendingEvents, func(e recordEvent) bool { return e.of(record) })
+}
+
+// retryWrites returns a command that triggers retrying of pending writes after
+// given delay.
+func retryWrites(delay time.Duration) tea.Cmd {
+	return tea.Tick(delay, func(time.Time) tea.Msg {
+		return retryWritesMsg{}
+	})
+}
+
+// nextRetryDelay returns the wait before the retry that follows a retry after
+// delay. Zero delay means there was no retry before.
+func nextRetryDelay(delay time.Duration) time.Duration {
+	if delay == 0 {
+		return writeBackoff
+	}
+	return min(2*delay, maxWriteBackoff)
+}
+
+// scheduleRetry returns a command that retries pending writes after a delay,
+// unless a retry is scheduled already. The delay grows with each retry until
+// a write succeeds.
+func (m *MyHours) scheduleRetry() tea.Cmd {
+	if m.state.retrying {
+		return nil
 	}
+	m.state.retrying = true
+	m.state.retryDelay = nextRetryDelay(m.state.retryDelay)
+	return retryWrites(m.state.retryDelay)
+}
+
+func (m *MyHours) updateGlobalCategoryID(id int64) tea.Cmd {
+	return m.updateSetting(SettingDefaultCategory, strconv.FormatInt(id, 10), func(s *Settings) {
+		s.DefaultCategoryID = id
+	})
 }
 
-func (m MyHours) updateGlobalCategoryID(id int64) tea.Cmd {
+// updateSetting returns a command that stores a setting value. Apply is used to
+// update the value to current settings once stored, or queued for retry. If
+// the setting is being written already, the value is queued behind that write
+// and applied right away.
+func (m *MyHours) updateSetting(key Setting, value string, apply func(*Settings)) tea.Cmd {
 	settings := m.settings
+	apply(&settings)
+	write := settingWrite{key: key, value: value}
+	m.state.pendingSettings = slices.DeleteFunc(m.state.pendingSettings, func(w settingWrite) bool { return w.key == key })
+	if slices.Contains(m.state.writingSettings, key) {
+		m.state.pendingSettings = append(m.state.pendingSettings, write)
+		return func() tea.Msg { return updateSettingsMsg{settings: settings} }
+	}
+	return m.flushSettings([]settingWrite{write}, &settings)
+}
+
+// flushSettings returns a command that stores the setting values in order, and
+// marks them being written. Settings are passed on in the result, to be
+// applied unless the write is given up on.
+func (m *MyHours) flushSettings(writes []settingWrite, settings *Settings) tea.Cmd {
+	for _, write := range writes {
+		m.state.writingSettings = append(m.state.writingSettings, write.key)
+	}
 	return func() tea.Msg {
-		if err := m.db.UpdateSetting(SettingDefaultCategory, strconv.FormatInt(id, 10)); err != nil {
-			m.l.Error("failed to update global category setting", slog.String("error", err.Error()))
-			return tea.Quit()
+		res := settingsWrittenMsg{settings: settings}
+		for _, write := range writes {
+			err := m.db.UpdateSetting(m.ctx, write.key, write.value)
+			switch {
+			case err == nil:
+				res.stored = append(res.stored, write)
+			case errors.Is(err, ErrTransient):
+				res.failed = append(res.failed, write)
+				res.err = fmt.Errorf("changing %s failed: %w", write.key, err)
+			default:
+				m.l.Error("failed to update setting", slog.String("key", write.key.String()), slog.String("error", err.Error()))
+				res.dropped = append(res.dropped, write)
+				res.droppedErr = fmt.Errorf("changing %s failed: %w", write.key, err)
+			}
 		}
-		settings.DefaultCategoryID = id
-		return updateSettingsMsg{settings: settings}
+		return res
 	}
 }
 
-func (m MyHours) updateReportData() tea.Cmd {
+// writeQueuedSetting returns a command that writes the value of the setting
+// queued while it was being written. Returns nil if there is none.
+func (m *MyHours) writeQueuedSetting(key Setting) tea.Cmd {
+	i := slices.IndexFunc(m.state.pendingSettings, func(w settingWrite) bool { return w.key == key })
+	if i < 0 {
+		return nil
+	}
+	write := m.state.pendingSettings[i]
+	m.state.pendingSettings = slices.Delete(m.state.pendingSettings, i, i+1)
+	return m.flushSettings([]settingWrite{write}, nil)
+}
+
+// unsaved returns the number of changes waiting to be stored.
+func (m *MyHours) unsaved() int {
+	return len(m.state.pendingWrites) + len(m.state.pendingEvents) + len(m.state.pendingSettings)
+}
+
+// setStatus shows a notification. Returns a command that clears it after a while.
+func (m *MyHours) setStatus(text string, isError bool) tea.Cmd {
+	m.state.statusID++
+	m.state.statusText = text
+	m.state.statusError = isError
+	id := m.state.statusID
+	return tea.Tick(statusTimeout, func(time.Time) tea.Msg {
+		return clearStatusMsg{id: id}
+	})
+}
+
+// updateReportData shows the report in view from the cache, or returns a
+// command that loads it. A load still running for an earlier view, page or
+// category is cancelled. Returns nil if the view is not a report view, or if
+// there's nothing to load.
+func (m *MyHours) updateReportData() tea.Cmd {
+	m.cancelReportLoad()
 	var (
-		r          report
-		viewID     = m.state.activeView
-		pageNo     = m.reportPageNo()
-		categoryID = m.settings.DefaultCategoryID
+		viewID = m.state.activeView
+		pageNo = m.reportPageNo()
+		key    = reportKey{viewID: viewID, pageNo: pageNo, categoryID: m.settings.DefaultCategoryID}
+		opts   = reportOptions{showBreaks: m.settings.ReportShowBreaks}
 	)
-	switch m.state.activeView {
-	case 1: // Weekly
-		r = reportWeekly
-	case 2: // Monthly
-		r = reportMonthly
-	case 3: // Yearly
-		r = reportYearly
-	default:
+	r, ok := reportForView(viewID)
+	if !ok {
 		// not a reporting view
 		return nil
 	}
+	from, _ := r.dates(m.reportNow(), pageNo)
+	// the page and the pages next to it are loaded with the same context, so
+	// that moving on cancels them all.
+	m.state.reportCtx, m.state.reportCancel = context.WithCancel(m.ctx)
+	if table, ok := m.state.reportCache.get(key, from, opts); ok {
+		m.showReport(table)
+		return m.prefetchReports()
+	}
+	m.state.reportLoading = true
+	return m.loadReport(m.state.reportCtx, r, key, false)
+}
+
+// prefetchReports returns a command that loads the pages before and after the
+// page in view into the cache, unless they are cached already. Loads are
+// cancelled along with the load of the page in view. Returns nil if there's
+// nothing to load.
+func (m *MyHours) prefetchReports() tea.Cmd {
+	r, ok := reportForView(m.state.activeView)
+	if !ok || m.state.reportCtx == nil {
+		return nil
+	}
+	var (
+		cache    = m.state.reportCache
+		opts     = reportOptions{showBreaks: m.settings.ReportShowBreaks}
+		commands []tea.Cmd
+	)
+	for _, pageNo := range []int{m.reportPageNo() - 1, m.reportPageNo() + 1} {
+		key := reportKey{viewID: m.state.activeView, pageNo: pageNo, categoryID: m.settings.DefaultCategoryID}
+		from, _ := r.dates(m.reportNow(), pageNo)
+		if _, ok := cache.get(key, from, opts); ok || pageNo > 0 || cache.pending[key] {
+			continue
+		}
+		cache.pending[key] = true
+		commands = append(commands, m.loadReport(m.state.reportCtx, r, key, true))
+	}
+	return tea.Batch(commands...)
+}
+
+// loadReport returns a command that loads a report page. The load is
+// cancelled with ctx.
+func (m *MyHours) loadReport(ctx context.Context, r report, key reportKey, prefetch bool) tea.Cmd {
+	var (
+		opts       = reportOptions{showBreaks: m.settings.ReportShowBreaks}
+		now        = m.reportNow()
+		from, _    = r.dates(now, key.pageNo)
+		generation = m.state.reportCache.generation
+		db, l      = m.db, m.l
+	)
 	return func() tea.Msg {
-		from, before := r.dates(pageNo)
-		res, err := m.db.RecordsInCategory(from, before, categoryID)
+		table, err := r.build(ctx, db, now, key.pageNo, key.categoryID, opts)
+		if ctx.Err() != nil {
+			// replaced by a newer load, no need to report anything.
+			return nil
+		}
+		msg := reportDataMsg{
+			viewID:     key.viewID,
+			pageNo:     key.pageNo,
+			categoryID: key.categoryID,
+			from:       from,
+			opts:       opts,
+			generation: generation,
+			prefetch:   prefetch,
+			title:      table.title,
+			headers:    table.headers,
+			rows:       table.rows,
+			style:      table.style,
+		}
 		if err != nil {
-			m.l.Error("failed to fetch records", slog.String("error", err.Error()))
-			return tea.Quit()
-		}
-		rows := r.mapper(res)
-		return reportDataMsg{
-			viewID:     viewID,
-			pageNo:     pageNo,
-			categoryID: categoryID,
-			title:      r.title(pageNo),
-			headers:    r.headers(),
-			rows:       rows,
-			style:      r.styles,
+			l.Error("failed to fetch records", slog.String("error", err.Error()))
+			msg.err = fmt.Errorf("loading report failed: %w", err)
+		}
+		return msg
+	}
+}
+
+// showReport puts the report table in view.
+func (m *MyHours) showReport(table reportTable) {
+	m.state.reportRows = table.rows
+	m.state.reportHeaders = table.headers
+	m.state.reportTitle = table.title
+	m.state.reportStyle = table.style
+	m.state.reportLoading = false
+	m.scrollReport()
+}
+
+// cancelReportLoad cancels the report load and prefetches in progress, if
+// any. Cancelled prefetches are no longer pending, so they can be started
+// again.
+func (m *MyHours) cancelReportLoad() {
+	if m.state.reportCancel != nil {
+		m.state.reportCancel()
+		m.state.reportCtx, m.state.reportCancel = nil, nil
+	}
+	clear(m.state.reportCache.pending)
+}
+
+// enableViewKeys enables the keys used in given view, and disables the keys
+// of other views.
+func (m *MyHours) enableViewKeys(viewID int) {
+	_, report := reportForView(viewID)
+	settings := viewID == settingsView
+	m.keys.nextReportPage.SetEnabled(report)
+	m.keys.prevReportPage.SetEnabled(report)
+	m.keys.reportRowUp.SetEnabled(report || settings)
+	m.keys.reportRowDown.SetEnabled(report || settings)
+	m.keys.sortReport.SetEnabled(report)
+	m.keys.reverseSort.SetEnabled(report)
+	m.keys.toggleBreaks.SetEnabled(report)
+	m.keys.editSetting.SetEnabled(settings)
+}
+
+// scrollReport keeps the selected report row within the report, and visible.
+func (m *MyHours) scrollReport() {
+	rows, totals := splitTotals(m.state.reportRows)
+	capacity := tableRowCapacity(reportTableHeight(m.contentHeight()), len(totals))
+	m.state.reportCursor = m.state.reportCursor.scroll(len(rows), capacity)
+}
+
+// setActiveRecord sets the active record, and updates key states to match.
+func (m *MyHours) setActiveRecord(record Record) {
+	m.state.activeRecord = record
+	// while record is active, can't start new one.
+	m.keys.newRecord.SetEnabled(!record.Active())
+	m.keys.startRecord.SetEnabled(!record.Active())
+	m.keys.stopRecord.SetEnabled(record.Active())
+	m.keys.startRecordAt.SetEnabled(!record.Active())
+	m.keys.stopRecordAt.SetEnabled(record.Active())
+	m.keys.switchRecord.SetEnabled(record.Active())
+	m.keys.pauseRecord.SetEnabled(record.Active() && !record.Paused())
+	m.keys.resumeRecord.SetEnabled(record.Paused())
+	m.keys.timebox.SetEnabled(record.Active())
+	// timebox only runs with an active record.
+	if !record.Active() {
+		m.state.timebox = timebox{}
+	}
+}
+
+// endBreaks returns a copy of breaks, with any break still on ended at given
+// time.
+func endBreaks(breaks []Break, at time.Time) []Break {
+	breaks = slices.Clone(breaks)
+	for i := range breaks {
+		if breaks[i].End.IsZero() {
+			breaks[i].End = at
 		}
 	}
+	return breaks
 }
 
 // reportPageNo returns the active page number for a report view.
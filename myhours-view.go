@@ -1,6 +1,7 @@
 package myhours
 
 import (
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -24,6 +25,9 @@ func (m MyHours) View() string {
 		// If state is not yet ready, just output a loading placeholder.
 		// We'll get here again once state is ready.
 		return m.renderFullscreen(m.renderLoadingScreen)
+	case m.state.prompt.kind != promptNone:
+		// Prompts take over the view area until they're closed.
+		return m.renderWithNavigation(m.renderPrompt)
 	case m.state.showHelp:
 		// Help is handled separately, we don't want to have the navigation and
 		// all other distractions visible when showing help.
@@ -34,8 +38,13 @@ func (m MyHours) View() string {
 		switch m.state.activeView {
 		case 0:
 			view = m.renderTimer
-		case 1, 2, 3:
+			if m.state.dashboard.enabled {
+				view = m.renderDashboard
+			}
+		case 1, 2, 3, 4:
 			view = m.renderReport
+		case settingsView:
+			view = m.renderSettings
 		default:
 			view = func(int, int) string { return "you should not get here.." }
 		}
@@ -46,7 +55,7 @@ func (m MyHours) View() string {
 // renderLoadingScreen that indicates something is not ready yet, but should be
 // soon. Usable as placeholder when waiting for data.
 func (m MyHours) renderLoadingScreen(width, height int) string {
-	return styleLoader.Height(height).Width(width).Render("Loading ...")
+	return m.styles.loader.Height(height).Width(width).Render("Loading ...")
 }
 
 // renderHelp for the application. This is the full help for the app, which
@@ -54,7 +63,10 @@ func (m MyHours) renderLoadingScreen(width, height int) string {
 func (m MyHours) renderHelp(width, _ int) string {
 	h := m.help
 	h.Width = width
+	// use a fresh key map, so that all keys are shown regardless of them being
+	// enabled or not. Bindings are already validated, so error can be ignored.
 	keys := newKeymap()
+	_ = keys.apply(m.bindings)
 	return lipgloss.Place(
 		m.state.viewWidth,
 		m.state.viewHeight,
@@ -70,6 +82,7 @@ func (m MyHours) renderHelp(width, _ int) string {
 				keys.switchGlobalCategory,
 				keys.nextTab,
 				keys.prevTab,
+				keys.palette,
 				keys.quit,
 				keys.fullScreen,
 				keys.closeHelp,
@@ -79,13 +92,27 @@ func (m MyHours) renderHelp(width, _ int) string {
 				// timer view keys
 				key.NewBinding(key.WithHelp("", "Timer:"), key.WithKeys("")),
 				keys.startRecord,
+				keys.startRecordAt,
 				keys.newRecord,
+				keys.switchRecord,
+				keys.pauseRecord,
+				keys.timebox,
+				keys.startTask,
+				keys.pinTask,
 				keys.switchTaskCategory,
 				key.NewBinding(key.WithHelp("", ""), key.WithKeys("")),
 				key.NewBinding(key.WithHelp("", "Reports:"), key.WithKeys("")),
 				// reporting keys
 				keys.prevReportPage,
 				keys.nextReportPage,
+				keys.reportRowUp,
+				keys.reportRowDown,
+				keys.sortReport,
+				keys.reverseSort,
+				keys.toggleBreaks,
+				key.NewBinding(key.WithHelp("", ""), key.WithKeys("")),
+				key.NewBinding(key.WithHelp("", "Settings:"), key.WithKeys("")),
+				keys.editSetting,
 			},
 		}),
 	)
@@ -94,13 +121,15 @@ func (m MyHours) renderHelp(width, _ int) string {
 // renderHelpHint renders the small help next to navigation. Basically just
 // telling what to press to see the actual help.
 func (m MyHours) renderHelpHint() string {
-	return m.help.ShortHelpView([]key.Binding{m.keys.openHelp})
+	return m.help.ShortHelpView([]key.Binding{m.keys.openHelp, m.keys.palette})
 }
 
-// renderShortHelp renders the short help for a view.
+// renderShortHelp renders the short help for a view. Help is kept on one line,
+// keys that don't fit are left for the full help.
 func (m MyHours) renderShortHelp(width int, keys ...key.Binding) string {
 	h := help.New()
-	h.Styles = styleHelp
+	h.Styles = m.styles.help
+	h.Width = width - styleShortHelp.GetHorizontalFrameSize()
 	return styleShortHelp.Width(width).Render(h.ShortHelpView(keys))
 }
 
@@ -112,10 +141,10 @@ func (m MyHours) renderNavigation() string {
 		var style lipgloss.Style
 		// style based on if view is active now or not.
 		if i == m.state.activeView {
-			name = "\uE617 " + name
-			style = styleNavActive
+			name = m.styles.glyphs.navActive + name
+			style = m.styles.navActive
 		} else {
-			style = styleNavInactive
+			style = m.styles.navInactive
 		}
 		sections = append(sections, style.Render(name))
 	}
@@ -124,14 +153,31 @@ func (m MyHours) renderNavigation() string {
 	cat := findCategory(m.categories, m.settings.DefaultCategoryID)
 	// then construct the navigation bar.
 	var doc strings.Builder
-	doc.WriteString(styleNavCap.Render("\uE0BA"))
-	doc.WriteString(styleModeIndicator.Foreground(cat.ForegroundColor()).Render(cat.Name))
-	doc.WriteString(styleNavInactive.Render("│"))
-	doc.WriteString(strings.Join(sections, styleNavJoiner.Render("╱")))
-	doc.WriteString(styleNavCap.Render("\uE0BC"))
+	doc.WriteString(m.styles.navCap.Render(m.styles.glyphs.navCapLeft))
+	doc.WriteString(m.styles.modeIndicator.Foreground(m.styles.categoryColor(cat)).Render(cat.Name))
+	doc.WriteString(m.styles.navInactive.Render(m.styles.glyphs.navDivider))
+	doc.WriteString(strings.Join(sections, m.styles.navJoiner.Render(m.styles.glyphs.navJoiner)))
+	doc.WriteString(m.styles.navCap.Render(m.styles.glyphs.navCapRight))
 	return doc.String()
 }
 
+// renderStatus renders the status notification line. If there's no notification,
+// but there are pending writes, a reminder of those is shown instead.
+func (m MyHours) renderStatus(width int) string {
+	var (
+		text  = m.state.statusText
+		style = m.styles.statusInfo
+	)
+	switch {
+	case text != "" && m.state.statusError:
+		style = m.styles.statusError
+	case text == "" && m.unsaved() > 0:
+		text = strconv.Itoa(m.unsaved()) + " unsaved change(s), retrying..."
+		style = m.styles.statusError
+	}
+	return style.Width(width).MaxHeight(1).Align(lipgloss.Center).Render(text)
+}
+
 // renderFullscreen renders the given content full screen, without anything else
 // on the screen. Given render function gets the view size adjusted to account
 // for any window styling.
@@ -139,15 +185,26 @@ func (m MyHours) renderFullscreen(render renderer) string {
 	return styleWindow.Render(render(m.state.viewWidth, m.state.viewHeight))
 }
 
+// contentHeight returns the height available for views rendered with
+// navigation.
+func (m MyHours) contentHeight() int {
+	_, navHeight := lipgloss.Size(m.renderNavigation())
+	return m.state.viewHeight - navHeight
+}
+
 // renderWithNavigation is used to render a component view with navigation. Given
 // render function gets width/height adjusted to account for the navigation.
 func (m MyHours) renderWithNavigation(render renderer) string {
-	viewHeight, viewWidth := m.state.viewHeight, m.state.viewWidth
+	viewWidth := m.state.viewWidth
 	nav := m.renderNavigation()
 	_, navHeight := lipgloss.Size(nav)
-	contentHeight := viewHeight - navHeight + 1
+	// status line is always reserved, so that the layout doesn't jump around
+	// when notifications come and go.
+	contentHeight := m.contentHeight()
 	doc := strings.Builder{}
-	doc.WriteString(lipgloss.Place(viewWidth, contentHeight, lipgloss.Center, lipgloss.Center, render(viewWidth, viewHeight)))
+	doc.WriteString(lipgloss.Place(viewWidth, contentHeight, lipgloss.Center, lipgloss.Center, render(viewWidth, contentHeight)))
+	doc.WriteString("\n")
+	doc.WriteString(m.renderStatus(viewWidth))
 	doc.WriteString("\n")
 	doc.WriteString(lipgloss.Place(viewWidth, navHeight, lipgloss.Center, lipgloss.Bottom, nav+" "+m.renderHelpHint()))
 	out := styleWindow.Height(m.state.viewHeight).Width(m.state.viewWidth).Render(doc.String())
@@ -161,7 +218,7 @@ func (m MyHours) renderTimer(width, _ int) string {
 	// get the current timer parameters, we'll render these with labels.
 	// elapsed is the time currently spent on the possibly active task. Should
 	// show idle or previous task if not running right now.
-	elapsed := m.timer.view()
+	elapsed := m.timer.view(m.styles.glyphs)
 	// started is the time when current/previous task was started.
 	started := m.timer.started()
 	// we also show the category assigned to the task. If a task ID exists, this
@@ -169,8 +226,8 @@ func (m MyHours) renderTimer(width, _ int) string {
 	cat := findCategory(m.categories, m.state.activeRecord.CategoryID)
 	// now we build the actual view.
 	var doc strings.Builder
-	doc.WriteString(styleTimerLabel.Render("Tracking:"))
-	doc.WriteString(lipgloss.NewStyle().Foreground(cat.ForegroundColor()).Render(cat.Name))
+	doc.WriteString(m.styles.timerLabel.Render("Tracking:"))
+	doc.WriteString(lipgloss.NewStyle().Foreground(m.styles.categoryColor(cat)).Render(cat.Name))
 	// display the active/previous ID, depending on which is found. If there's
 	// an active record ID, then a record is running right now.
 	// If only previous record ID set, then a record was made, but stopped.
@@ -180,26 +237,62 @@ func (m MyHours) renderTimer(width, _ int) string {
 		doc.WriteString(")")
 	}
 	doc.WriteString("\n")
-	doc.WriteString(styleTimerLabel.Render("Started:"))
+	if m.state.activeRecord.Notes != "" {
+		doc.WriteString(m.styles.timerLabel.Render("Notes:"))
+		doc.WriteString(m.state.activeRecord.Notes)
+		doc.WriteString("\n")
+	}
+	doc.WriteString(m.styles.timerLabel.Render("Started:"))
 	// if task started is zero, we'll just omit the detail nothing is/has been running.
 	if !started.IsZero() {
 		doc.WriteString(started.Format(time.DateTime + " -0700"))
 	}
 	doc.WriteString("\n")
-	doc.WriteString(styleTimerLabel.Render("Now:"))
+	doc.WriteString(m.styles.timerLabel.Render("Now:"))
 	if !started.IsZero() {
-		doc.WriteString(time.Now().Format(time.DateTime + " -0700"))
+		doc.WriteString(m.now().Format(time.DateTime + " -0700"))
 	}
 	doc.WriteString("\n")
-	doc.WriteString(styleTimerLabel.Render("Elapsed:"))
+	doc.WriteString(m.styles.timerLabel.Render("Elapsed:"))
 	doc.WriteString(elapsed)
 	doc.WriteString("\n")
-	// Form the container style and render the document into it.
-	style := styleTimerContainer.Width(w).BorderForeground(cat.ForegroundColor())
+	if breaks := m.timer.breakTime(m.now()); breaks > 0 {
+		doc.WriteString(m.styles.timerLabel.Render("Breaks:"))
+		doc.WriteString(breaks.Truncate(time.Second).String())
+		doc.WriteString("\n")
+	}
+	// timebox shows the progress of current phase, and pomodoros done.
+	now := m.now()
+	if b := m.state.timebox; b.phase != phaseOff {
+		doc.WriteString(m.styles.timerLabel.Render("Timebox:"))
+		doc.WriteString(progressBar(12, b.progress(now)))
+		doc.WriteString(" ")
+		doc.WriteString(b.remaining(now).Truncate(time.Second).String())
+		doc.WriteString("\n")
+	}
+	if m.state.activeRecord.Pomodoros > 0 || m.state.timebox.phase != phaseOff {
+		doc.WriteString(m.styles.timerLabel.Render("Pomodoros:"))
+		doc.WriteString(strconv.Itoa(m.state.activeRecord.Pomodoros))
+		if m.state.timebox.phase != phaseOff {
+			doc.WriteString(" (")
+			doc.WriteString(m.state.timebox.phase.String())
+			doc.WriteString(")")
+		}
+		doc.WriteString("\n")
+	}
+	doc.WriteString("\n")
+	doc.WriteString(m.renderTotals())
+	// Form the container style and render the document into it. The border
+	// flashes when a timebox phase ends.
+	style := styleTimerContainer.Width(w).BorderForeground(m.styles.categoryColor(cat))
+	if m.state.timebox.flashing(now) {
+		style = style.BorderForeground(m.styles.statusError.GetForeground())
+	}
 	var box strings.Builder
 	box.WriteString(style.Render(doc.String()))
 	box.WriteString("\n")
-	box.WriteString(m.renderShortHelp(width, m.keys.newRecord, m.keys.startRecord, m.keys.stopRecord))
+	box.WriteString(m.renderTasks(w))
+	box.WriteString(m.renderShortHelp(width, m.keys.newRecord, m.keys.startRecord, m.keys.stopRecord, m.keys.startRecordAt, m.keys.stopRecordAt, m.keys.pauseRecord, m.keys.resumeRecord, m.keys.switchRecord, m.keys.timebox))
 	return box.String()
 }
 
@@ -210,44 +303,95 @@ func (m MyHours) renderReport(width, height int) string {
 	if m.state.reportLoading {
 		return m.renderLoadingScreen(width, height)
 	}
+	container := styleReportContainer.Width(width)
+	cursor := m.state.reportCursor
+	var doc strings.Builder
+	doc.WriteString(m.renderReportTable(width, reportTableHeight(height), reportTable{
+		title:   m.state.reportTitle,
+		headers: m.state.reportHeaders,
+		rows:    m.state.reportRows,
+		style:   m.state.reportStyle,
+	}, &cursor))
+	doc.WriteString("\n")
+	doc.WriteString(m.renderShortHelp(width, m.keys.prevReportPage, m.keys.nextReportPage, m.keys.reportRowUp, m.keys.reportRowDown, m.keys.sortReport, m.keys.reverseSort, m.keys.toggleBreaks))
+	return container.Render(doc.String())
+}
+
+// reportTableHeight returns the height available for the table in a report
+// view of given height. Title and short help take a line each.
+func reportTableHeight(height int) int {
+	return height - styleReportContainer.GetVerticalFrameSize() - 2
+}
+
+// renderReportTable renders a titled report table, to fit into a report
+// container of given width. Table is limited to given height by scrolling
+// rows, keeping totals rows visible.
+//
+// If cursor is given, the rows are sorted and scrolled accordingly, and the
+// selected row is highlighted. Otherwise rows are shown from the top.
+func (m MyHours) renderReportTable(width, tableHeight int, data reportTable, cursor *reportCursor) string {
 	var (
 		// We have to calculate some dimensions for the table to make it fit a bit
 		// better and ensure it's getting clipped correctly if needed.
-		container   = styleReportContainer.Width(width)
-		tableWidth  = width - container.GetHorizontalFrameSize()
-		tableHeight = height - container.GetVerticalFrameSize()
+		container  = styleReportContainer.Width(width)
+		tableWidth = width - container.GetHorizontalFrameSize()
 		// select currently active category, we'll render it also on top of the table.
 		cat      = findCategory(m.categories, m.settings.DefaultCategoryID)
-		catStyle = lipgloss.NewStyle().Foreground(cat.ForegroundColor())
+		catStyle = lipgloss.NewStyle().Foreground(m.styles.categoryColor(cat))
+		// totals rows are pinned to the bottom, rest of the rows scroll.
+		rows, totals = splitTotals(data.rows)
+		capacity     = tableRowCapacity(tableHeight, len(totals))
+		headers      = slices.Clone(data.headers)
+		selected     = -1
 	)
+	if cursor != nil {
+		rows = sortRows(rows, cursor.sortBy, cursor.sortDesc)
+		c := cursor.scroll(len(rows), capacity)
+		if len(rows) > 0 {
+			selected = c.row - c.offset
+		}
+		rows = rows[c.offset:]
+		if cursor.sortBy > 0 && cursor.sortBy <= len(headers) {
+			if cursor.sortDesc {
+				headers[cursor.sortBy-1] += " ▼"
+			} else {
+				headers[cursor.sortBy-1] += " ▲"
+			}
+		}
+	}
+	rows = append(slices.Clone(rows[:min(capacity, len(rows))]), totals...)
 	// create the new table.
 	tbl := table.New().Width(tableWidth).Height(tableHeight)
 	// attach data to it.
-	tbl = tbl.Headers(m.state.reportHeaders...).Rows(m.state.reportRows...)
+	tbl = tbl.Headers(headers...).Rows(rows...)
 	// add styling instructions. We use are wrapper to have access to the table
 	// row data, as we want to style some things based on content.
-	tbl = tbl.StyleFunc(tableStyleWrapper(m.state.reportStyle, m.state.reportHeaders, m.state.reportRows))
+	style := tableStyleWrapper(m.styles, data.style, headers, rows)
+	tbl = tbl.StyleFunc(func(r, c int) lipgloss.Style {
+		if r == selected {
+			return style(r, c).Inherit(m.styles.tableCursor)
+		}
+		return style(r, c)
+	})
 	// build the table title first.
 	var title strings.Builder
 	title.WriteString(catStyle.Render(cat.Name))
 	title.WriteString(": ")
-	title.WriteString(m.state.reportTitle)
-	// then build the whole report view content by combining a stylized title
-	// and the renderer table.
+	title.WriteString(data.title)
+	// then build the whole report content by combining a stylized title and
+	// the rendered table.
 	var doc strings.Builder
 	doc.WriteString(styleReportTitle.Render(title.String()))
 	doc.WriteString("\n")
 	doc.WriteString(tbl.Render())
-	doc.WriteString("\n")
-	doc.WriteString(m.renderShortHelp(width, m.keys.prevReportPage, m.keys.nextReportPage))
-	return container.Render(doc.String())
+	return doc.String()
 }
 
-func tableStyleWrapper(cellStyler func(int, int, []string) lipgloss.Style, headers []string, rows [][]string) func(int, int) lipgloss.Style {
+func tableStyleWrapper(s styles, cellStyler reportStyleFunc, headers []string, rows [][]string) func(int, int) lipgloss.Style {
 	return func(r, c int) lipgloss.Style {
 		if r == -1 {
-			return cellStyler(r, c, headers)
+			return cellStyler(s, r, c, headers)
 		}
-		return cellStyler(r, c, rows[r])
+		return cellStyler(s, r, c, rows[r])
 	}
 }
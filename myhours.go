@@ -6,6 +6,7 @@
 package myhours
 
 import (
+	"context"
 	"log/slog"
 	"time"
 
@@ -27,6 +28,23 @@ func UseLogger(l *slog.Logger) Option {
 	}
 }
 
+// UseTheme sets the theme used for rendering the application. See BuiltinTheme
+// for available built-in themes.
+func UseTheme(t Theme) Option {
+	return func(app *MyHours) {
+		app.styles = newStyles(t)
+	}
+}
+
+// UseKeyBindings overrides the default key bindings of the application. Use
+// KeyBindings.Validate to check the bindings beforehand, invalid bindings are
+// logged and ignored.
+func UseKeyBindings(b KeyBindings) Option {
+	return func(app *MyHours) {
+		app.bindings = b
+	}
+}
+
 // New returns an initialized MyHours model that can be passed into a
 // bubbletea program for running the time tracking application.
 //
@@ -35,23 +53,29 @@ func UseLogger(l *slog.Logger) Option {
 //
 // To use the returned model, call for example tea.NewProgram(model).Run()
 func New(db Database, options ...Option) MyHours {
-	h := help.New()
-	h.Styles = styleHelp
-
+	defaultTheme, _ := BuiltinTheme("default")
+	ctx, cancel := context.WithCancel(context.Background())
 	app := MyHours{
-		db:    db,
-		l:     slog.New(slog.DiscardHandler),
-		help:  h,
-		timer: newTimer(time.Millisecond * 250),
+		ctx:    ctx,
+		cancel: cancel,
+		db:     db,
+		l:      slog.New(slog.DiscardHandler),
+		help:   help.New(),
+		styles: newStyles(defaultTheme),
+		clock:  SystemClock{},
+		timer:  newTimer(time.Millisecond*250, SystemClock{}),
 		state: state{
-			reportPage: make([]int, 4),
+			reportPage:  make([]int, 5),
+			reportCache: newReportCache(),
 		},
 		keys: newKeymap(),
 		viewNames: []string{
 			"Timer",
+			"Day",
 			"Week",
 			"Month",
 			"Year",
+			"Settings",
 		},
 	}
 	// disable all keys by default (except quite). They'll be enabled once app
@@ -62,15 +86,39 @@ func New(db Database, options ...Option) MyHours {
 	app.keys.prevTab.SetEnabled(false)
 	app.keys.prevReportPage.SetEnabled(false)
 	app.keys.nextReportPage.SetEnabled(false)
+	app.keys.reportRowUp.SetEnabled(false)
+	app.keys.reportRowDown.SetEnabled(false)
+	app.keys.sortReport.SetEnabled(false)
+	app.keys.reverseSort.SetEnabled(false)
 	app.keys.startRecord.SetEnabled(false)
 	app.keys.stopRecord.SetEnabled(false)
+	app.keys.startRecordAt.SetEnabled(false)
+	app.keys.stopRecordAt.SetEnabled(false)
 	app.keys.newRecord.SetEnabled(false)
+	app.keys.switchRecord.SetEnabled(false)
+	app.keys.pauseRecord.SetEnabled(false)
+	app.keys.resumeRecord.SetEnabled(false)
+	app.keys.toggleBreaks.SetEnabled(false)
+	app.keys.timebox.SetEnabled(false)
+	app.keys.startTask.SetEnabled(false)
+	app.keys.pinTask.SetEnabled(false)
+	app.keys.palette.SetEnabled(false)
+	app.keys.editSetting.SetEnabled(false)
 	app.keys.openHelp.SetEnabled(false)
 	app.keys.closeHelp.SetEnabled(false)
+	keys := app.keys
 	// apply options to customize the application.
 	for _, opt := range options {
 		opt(&app)
 	}
+	app.help.Styles = app.styles.help
+	// apply custom key bindings on top of the defaults. On failure we fall back
+	// to the defaults, keeping the enabled state of keys as is.
+	if err := app.keys.apply(app.bindings); err != nil {
+		app.l.Error("invalid key bindings, using defaults", slog.String("error", err.Error()))
+		app.bindings = nil
+		app.keys = keys
+	}
 	return app
 }
 
@@ -83,29 +131,82 @@ type state struct {
 	activeView   int
 	activeRecord Record
 	showHelp     bool
-	ready        bool
-	quitting     bool
+	// prompt is the open modal prompt, if any.
+	prompt prompt
+	// dashboard is the layout used for timer view on wide terminals.
+	dashboard dashboard
+	// totals of time spent today and this week.
+	totals totals
+	// recentTasks are the latest distinct tasks from records.
+	recentTasks []Task
+	// timebox counts down pomodoros for the active record, if set.
+	timebox timebox
+	// status notification shown to the user.
+	statusID    int
+	statusText  string
+	statusError bool
+	// pendingWrites are records that failed to be stored, waiting for retry, or
+	// that wait for a write in flight.
+	pendingWrites []Record
+	// writing are the records with a write in flight.
+	writing []Record
+	// pendingEvents are breaks and pomodoros waiting for their record to be
+	// stored, or for retry.
+	pendingEvents []recordEvent
+	// pendingSettings are setting values waiting for retry, or for a write of
+	// the same setting in flight.
+	pendingSettings []settingWrite
+	// writingSettings are the settings with a write in flight.
+	writingSettings []Setting
+	// retrying is set while a retry of pending writes is scheduled.
+	retrying bool
+	// retryDelay is the wait before the latest retry, zero once a write
+	// succeeds.
+	retryDelay time.Duration
+	ready      bool
+	quitting   bool
 	// reporting data fields
 	reportLoading bool
+	// reportCtx is the context of loading the report page in view and
+	// prefetching the pages next to it, cancelled with reportCancel.
+	reportCtx     context.Context
+	reportCancel  context.CancelFunc
 	reportPage    []int
 	reportTitle   string
 	reportHeaders []string
 	reportStyle   reportStyleFunc
 	reportRows    [][]string
+	reportCursor  reportCursor
+	// reportCache keeps recently shown and prefetched report pages. The cache
+	// is shared by all copies of the model, so it must only be used from
+	// Update and the methods it calls. Commands get what they need of it when
+	// they are created.
+	reportCache *reportCache
+	// settingsCursor selects a setting in the settings view.
+	settingsCursor reportCursor
 }
 
 // MyHours is the my-hours application model. Keep track of the whole application
 // state and implements tea.Model.
 type MyHours struct {
+	// ctx is the context of database operations, cancelled when the
+	// application quits. Shared by all copies of the model.
+	ctx        context.Context
+	cancel     context.CancelFunc
 	db         Database
 	l          *slog.Logger
 	settings   Settings
 	categories []Category
 	viewNames  []string
 	keys       keymap
+	bindings   KeyBindings
 	state      state
 	help       help.Model
+	styles     styles
 	timer      timer
+	clock      Clock
+	// reportClock tells the time reports are shown as of. Nil follows clock.
+	reportClock Clock
 }
 
 func incMax(v, max int) int {
@@ -140,6 +241,66 @@ func decWrap(v, min, max int) int {
 	}
 }
 
+// sameRecord returns true if a and b are versions of the same record. Records
+// without ID are identified by the starting time.
+func sameRecord(a, b Record) bool {
+	if a.ID > 0 || b.ID > 0 {
+		return a.ID == b.ID
+	}
+	return a.Start.Equal(b.Start)
+}
+
+// queueWrite adds the record to the queue of pending writes. Any queued version
+// of the same record is replaced.
+func queueWrite(queue []Record, record Record) []Record {
+	for i := range queue {
+		if sameRecord(queue[i], record) {
+			queue[i] = record
+			return queue
+		}
+	}
+	return append(queue, record)
+}
+
+// settingWrite is a setting value to store.
+type settingWrite struct {
+	key   Setting
+	value string
+}
+
+// recordEventKind is the kind of change a recordEvent makes.
+type recordEventKind int
+
+const (
+	eventPause recordEventKind = iota
+	eventResume
+	eventPomodoro
+)
+
+// recordEvent is a change to a record that is stored on its own: a break
+// started or ended, or a pomodoro completed.
+type recordEvent struct {
+	kind recordEventKind
+	// recordID of the record, zero until the record is stored.
+	recordID int64
+	// start of the record, identifies the record until it has an ID.
+	start time.Time
+	at    time.Time
+}
+
+// newRecordEvent returns an event of given kind for the record.
+func newRecordEvent(kind recordEventKind, record Record, at time.Time) recordEvent {
+	return recordEvent{kind: kind, recordID: record.ID, start: record.Start, at: at}
+}
+
+// of returns true if the event is of given record.
+func (e recordEvent) of(record Record) bool {
+	if e.recordID > 0 {
+		return e.recordID == record.ID
+	}
+	return e.start.Equal(record.Start)
+}
+
 // indexOrZero returns the value from set in given index, or if index does not
 // exist in the given slice, the zero value of type T.
 func indexOrZero[T comparable](set []T, index int) T {
@@ -0,0 +1,212 @@
+package myhours_test
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/msepp/myhours"
+	"github.com/msepp/myhours/database/memory"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+// settleTimeout is how long commands are waited for. Commands still running
+// after it are ticks, which are not needed for rendering. They are left running
+// and waited for when the test ends.
+const settleTimeout = 100 * time.Millisecond
+
+// harness drives the application model with scripted messages, running the
+// commands it returns until there's nothing more to do.
+type harness struct {
+	t     *testing.T
+	model tea.Model
+	clock *myhours.FakeClock
+	wg    sync.WaitGroup // running commands
+}
+
+// newHarness returns a harness for the application with an in-memory database
+// of sample records, and a clock stopped at Wednesday 2026-01-07 10:30 UTC.
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+	// views show times in local time, use a fixed location.
+	local := time.Local
+	time.Local = time.UTC
+	t.Cleanup(func() { time.Local = local })
+	var (
+		ctx   = context.Background()
+		clock = myhours.NewFakeClock(time.Date(2026, 1, 7, 10, 30, 0, 0, time.UTC))
+		db    = memory.NewMemory()
+		at    = func(d, h, m int) time.Time { return time.Date(2026, 1, d, h, m, 0, 0, time.UTC) }
+	)
+	_, err := db.ImportRecords(ctx, []myhours.Record{
+		{Start: at(5, 9, 0), End: at(5, 9, 15), CategoryID: 2, Notes: "standup"},
+		{Start: at(5, 9, 30), End: at(5, 12, 0), CategoryID: 2, Notes: "code review", Pomodoros: 2},
+		{Start: at(6, 9, 0), End: at(6, 9, 15), CategoryID: 2, Notes: "standup"},
+		{
+			Start: at(6, 10, 0), End: at(6, 15, 30), CategoryID: 2, Notes: "PROJ-101",
+			Breaks: []myhours.Break{{Start: at(6, 12, 0), End: at(6, 12, 30)}},
+		},
+		{Start: at(6, 19, 0), End: at(6, 20, 0), CategoryID: 3, Notes: "guitar"},
+		{Start: at(7, 9, 0), End: at(7, 9, 15), CategoryID: 2, Notes: "standup"},
+	})
+	if err != nil {
+		t.Fatalf("ImportRecords() error = %v", err)
+	}
+	if _, err = db.StartRecord(ctx, at(7, 9, 30), 2, "PROJ-117"); err != nil {
+		t.Fatalf("StartRecord() error = %v", err)
+	}
+	if err = db.UpdateSetting(ctx, myhours.SettingDefaultCategory, "2"); err != nil {
+		t.Fatalf("UpdateSetting() error = %v", err)
+	}
+	h := &harness{t: t, model: myhours.New(db, myhours.UseClock(clock)), clock: clock}
+	// commands must not outlive the test, they read the time zone restored on
+	// cleanup.
+	t.Cleanup(h.wg.Wait)
+	return h
+}
+
+// send messages to the model, settling after each.
+func (h *harness) send(msgs ...tea.Msg) *harness {
+	for _, msg := range msgs {
+		var cmd tea.Cmd
+		h.model, cmd = h.model.Update(msg)
+		h.settle(cmd)
+	}
+	return h
+}
+
+// keys sends key presses, given as runes or names like "f1".
+func (h *harness) keys(keys ...string) *harness {
+	for _, k := range keys {
+		msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		for kt, name := range keyNames {
+			if name == k {
+				msg = tea.KeyMsg{Type: kt}
+			}
+		}
+		h.send(msg)
+	}
+	return h
+}
+
+// keyNames are the named keys used in tests.
+var keyNames = map[tea.KeyType]string{tea.KeyF1: "f1", tea.KeyEsc: "esc", tea.KeyEnter: "enter"}
+
+// init runs the initialization of the model.
+func (h *harness) init() *harness {
+	h.settle(h.model.Init())
+	return h
+}
+
+// settle runs commands in waves: all commands of a wave run concurrently, and
+// their messages are handed to the model in command order. Commands returned
+// from the model form the next wave. Sequences and batches are flattened.
+func (h *harness) settle(cmd tea.Cmd) {
+	cmdsType := reflect.TypeFor[[]tea.Cmd]()
+	wave := []tea.Cmd{cmd}
+	for len(wave) > 0 {
+		results := make([]chan tea.Msg, len(wave))
+		for i, cmd := range wave {
+			results[i] = make(chan tea.Msg, 1)
+			if cmd == nil {
+				results[i] <- nil
+				continue
+			}
+			h.wg.Add(1)
+			go func() {
+				defer h.wg.Done()
+				results[i] <- cmd()
+			}()
+		}
+		deadline := time.After(settleTimeout)
+		var next []tea.Cmd
+		for _, result := range results {
+			var msg tea.Msg
+			select {
+			case msg = <-result:
+			case <-deadline:
+				continue
+			}
+			if v := reflect.ValueOf(msg); v.IsValid() && v.Type().ConvertibleTo(cmdsType) {
+				next = append(next, v.Convert(cmdsType).Interface().([]tea.Cmd)...)
+				continue
+			}
+			if msg == nil {
+				continue
+			}
+			if _, ok := msg.(tea.QuitMsg); ok {
+				h.t.Fatal("application quit")
+			}
+			var cmd tea.Cmd
+			h.model, cmd = h.model.Update(msg)
+			next = append(next, cmd)
+		}
+		wave = next
+	}
+}
+
+// golden compares the view of the model to the golden file of the test,
+// updating the file instead with -update.
+func (h *harness) golden() {
+	h.t.Helper()
+	path := filepath.Join("testdata", "golden", filepath.FromSlash(h.t.Name())+".golden")
+	view := h.model.View()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			h.t.Fatalf("os.MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(view), 0644); err != nil {
+			h.t.Fatalf("os.WriteFile: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		h.t.Fatalf("os.ReadFile: %v, run with -update to create golden files", err)
+	}
+	if view != string(want) {
+		h.t.Errorf("view does not match %s, run with -update to accept the changes.\ngot:\n%s\nwant:\n%s", path, view, want)
+	}
+}
+
+func TestView(t *testing.T) {
+	size := tea.WindowSizeMsg{Width: 100, Height: 30}
+	tests := []struct {
+		name   string
+		script func(h *harness)
+	}{
+		{name: "loading", script: func(h *harness) { h.send(size) }},
+		{name: "timer", script: func(h *harness) { h.send(size).init() }},
+		{name: "timer_elapsed", script: func(h *harness) {
+			h.send(size).init()
+			h.clock.Advance(90 * time.Minute)
+			h.send(size)
+		}},
+		{name: "dashboard", script: func(h *harness) {
+			h.send(tea.WindowSizeMsg{Width: 160, Height: 40}).init()
+		}},
+		{name: "day", script: func(h *harness) { h.send(size).init().keys("l") }},
+		{name: "day_previous", script: func(h *harness) { h.send(size).init().keys("l", "k") }},
+		{name: "week", script: func(h *harness) { h.send(size).init().keys("l", "l") }},
+		{name: "month", script: func(h *harness) { h.send(size).init().keys("l", "l", "l") }},
+		{name: "year", script: func(h *harness) { h.send(size).init().keys("l", "l", "l", "l") }},
+		{name: "help", script: func(h *harness) { h.send(size).init().keys("f1") }},
+		{name: "narrow", script: func(h *harness) {
+			h.send(tea.WindowSizeMsg{Width: 60, Height: 20}).init().keys("l", "l")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t)
+			tt.script(h)
+			h.golden()
+		})
+	}
+}
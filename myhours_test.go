@@ -1,8 +1,21 @@
 package myhours
 
 import (
+	"cmp"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"iter"
+	"maps"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
 )
 
 func Test_incMax(t *testing.T) {
@@ -272,3 +285,1372 @@ func Test_nextCategoryID(t *testing.T) {
 		})
 	}
 }
+
+func Test_keymap_apply(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides KeyBindings
+		wantErr   bool
+		wantHelp  string
+	}{
+		{name: "no overrides", overrides: nil, wantHelp: "h, ←"},
+		{name: "rebind", overrides: KeyBindings{"prev_view": {"left", "y"}}, wantHelp: "←, y"},
+		{name: "unknown action", overrides: KeyBindings{"fly": {"f"}}, wantErr: true},
+		{name: "no keys", overrides: KeyBindings{"prev_view": {}}, wantErr: true},
+		{name: "conflict", overrides: KeyBindings{"prev_view": {"q"}}, wantErr: true},
+		{name: "swap keys", overrides: KeyBindings{"prev_view": {"l"}, "next_view": {"h"}}, wantHelp: "l"},
+		{name: "shared toggle keys", overrides: KeyBindings{"start": {"x"}, "stop": {"x"}}, wantHelp: "h, ←"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := newKeymap()
+			err := keys.apply(tt.overrides)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := keys.prevTab.Help().Key; got != tt.wantHelp {
+				t.Errorf("apply() help = %v, want %v", got, tt.wantHelp)
+			}
+		})
+	}
+}
+
+func Test_queueWrite(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Hour)
+	tests := []struct {
+		name   string
+		queue  []Record
+		record Record
+		want   []Record
+	}{
+		{
+			name:   "empty queue",
+			record: Record{Start: t0},
+			want:   []Record{{Start: t0}},
+		},
+		{
+			name:   "replace unsaved record by start",
+			queue:  []Record{{ID: 3, Start: t0}, {Start: t0}},
+			record: Record{Start: t0, End: t1},
+			want:   []Record{{ID: 3, Start: t0}, {Start: t0, End: t1}},
+		},
+		{
+			name:   "replace by id",
+			queue:  []Record{{ID: 3, Start: t0}},
+			record: Record{ID: 3, Start: t0, End: t1},
+			want:   []Record{{ID: 3, Start: t0, End: t1}},
+		},
+		{
+			name:   "append different record",
+			queue:  []Record{{ID: 3, Start: t0}},
+			record: Record{ID: 4, Start: t0},
+			want:   []Record{{ID: 3, Start: t0}, {ID: 4, Start: t0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := queueWrite(tt.queue, tt.record); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("queueWrite() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// writingDatabase records writes, failing the writes of records and events
+// with the errors set for them.
+type writingDatabase struct {
+	Database
+	writes                          []string
+	recordErr, eventErr, settingErr error
+}
+
+func (db *writingDatabase) UpdateSetting(_ context.Context, key Setting, value string) error {
+	if db.settingErr != nil {
+		return db.settingErr
+	}
+	db.writes = append(db.writes, fmt.Sprintf("%s=%s", key, value))
+	return nil
+}
+
+func (db *writingDatabase) StartRecord(_ context.Context, start time.Time, _ int64, _ string) (int64, error) {
+	if db.recordErr != nil {
+		return 0, db.recordErr
+	}
+	db.writes = append(db.writes, "start")
+	return 1, nil
+}
+
+func (db *writingDatabase) UpdateRecord(_ context.Context, recordID int64, _ int64, _, _ time.Time, _ string) error {
+	if db.recordErr != nil {
+		return db.recordErr
+	}
+	db.writes = append(db.writes, fmt.Sprintf("update %d", recordID))
+	return nil
+}
+
+func (db *writingDatabase) ImportRecords(_ context.Context, records []Record) ([]int64, error) {
+	if db.recordErr != nil {
+		return nil, db.recordErr
+	}
+	db.writes = append(db.writes, "import")
+	return []int64{3}, nil
+}
+
+func (db *writingDatabase) SwitchRecord(_ context.Context, end, start time.Time, _ int64, _ string) (int64, error) {
+	if db.recordErr != nil {
+		return 0, db.recordErr
+	}
+	db.writes = append(db.writes, fmt.Sprintf("switch %s %s", end.Format(time.TimeOnly), start.Format(time.TimeOnly)))
+	return 2, nil
+}
+
+func (db *writingDatabase) event(name string, recordID int64) error {
+	if db.eventErr != nil {
+		return db.eventErr
+	}
+	db.writes = append(db.writes, fmt.Sprintf("%s %d", name, recordID))
+	return nil
+}
+
+func (db *writingDatabase) PauseRecord(_ context.Context, recordID int64, _ time.Time) error {
+	return db.event("pause", recordID)
+}
+
+func (db *writingDatabase) ResumeRecord(_ context.Context, recordID int64, _ time.Time) error {
+	return db.event("resume", recordID)
+}
+
+func (db *writingDatabase) CompletePomodoro(_ context.Context, recordID int64, _ time.Time) error {
+	return db.event("pomodoro", recordID)
+}
+
+func Test_flushWrites(t *testing.T) {
+	var (
+		t0        = time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+		record    = Record{Start: t0, CategoryID: 1}
+		events    = []recordEvent{{kind: eventPause, recordID: 1, at: t0}, {kind: eventResume, recordID: 1, at: t0}}
+		transient = fmt.Errorf("%w: database is locked", ErrTransient)
+		permanent = errors.New("active record already exists")
+	)
+	tests := []struct {
+		name                string
+		recordErr, eventErr error
+		wantWrites          []string
+		wantFailed          int
+		wantFailedEvents    int
+		wantDropped         int
+	}{
+		{name: "stored", wantWrites: []string{"start", "pause 1", "resume 1"}},
+		{name: "record locked", recordErr: transient, wantWrites: []string{"pause 1", "resume 1"}, wantFailed: 1},
+		{name: "record rejected", recordErr: permanent, wantWrites: []string{"pause 1", "resume 1"}, wantDropped: 1},
+		// events wait behind the first one that failed.
+		{name: "events locked", eventErr: transient, wantWrites: []string{"start"}, wantFailedEvents: 2},
+		{name: "events rejected", eventErr: permanent, wantWrites: []string{"start"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &writingDatabase{recordErr: tt.recordErr, eventErr: tt.eventErr}
+			res := New(db).flushWrites([]Record{record}, events)().(pendingWritesMsg)
+			if !slices.Equal(db.writes, tt.wantWrites) {
+				t.Errorf("writes = %v, want %v", db.writes, tt.wantWrites)
+			}
+			if len(res.failed) != tt.wantFailed || len(res.failedEvents) != tt.wantFailedEvents || len(res.dropped) != tt.wantDropped {
+				t.Errorf("flushWrites() = %d failed, %d failed events, %d dropped, want %d, %d, %d",
+					len(res.failed), len(res.failedEvents), len(res.dropped), tt.wantFailed, tt.wantFailedEvents, tt.wantDropped)
+			}
+			if (res.err != nil) != (tt.wantFailed+tt.wantFailedEvents > 0) || (res.droppedErr != nil) != errors.Is(cmp.Or(tt.recordErr, tt.eventErr), permanent) {
+				t.Errorf("flushWrites() err = %v, droppedErr = %v", res.err, res.droppedErr)
+			}
+		})
+	}
+}
+
+func Test_writeEvent(t *testing.T) {
+	var (
+		db     = &writingDatabase{recordErr: fmt.Errorf("%w: database is locked", ErrTransient)}
+		m      = New(db)
+		t0     = time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+		record = Record{Start: t0, CategoryID: 1}
+	)
+	update := func(msg tea.Msg) tea.Cmd {
+		model, cmd := m.Update(msg)
+		m = model.(MyHours)
+		return cmd
+	}
+	// inserting the record fails for now, it waits for retry along with its
+	// break.
+	update(m.updateRecord(record)())
+	if cmd := m.writeEvent(newRecordEvent(eventPause, record, t0.Add(time.Minute))); cmd != nil {
+		t.Error("writeEvent() returned a command for record without ID")
+	}
+	if len(m.state.pendingWrites) != 1 || len(m.state.pendingEvents) != 1 || !m.state.retrying {
+		t.Fatalf("pending %d writes, %d events, retrying %v, want 1 each and retrying", len(m.state.pendingWrites), len(m.state.pendingEvents), m.state.retrying)
+	}
+	// on retry, the record is stored first, then its break with the new ID.
+	db.recordErr = nil
+	cmd := update(update(retryWritesMsg{})())
+	if len(m.state.pendingEvents) != 0 {
+		t.Fatalf("pending events = %v, want break on its way", m.state.pendingEvents)
+	}
+	// the break is stored first, the rest shows status.
+	update(cmd().(tea.BatchMsg)[0]())
+	if want := []string{"start", "pause 1"}; !slices.Equal(db.writes, want) {
+		t.Errorf("writes = %v, want %v", db.writes, want)
+	}
+	// records that can't be stored take their events with them.
+	m.writeEvent(newRecordEvent(eventPause, Record{Start: t0.Add(time.Hour)}, t0.Add(time.Hour)))
+	update(writeFailedMsg{record: Record{Start: t0.Add(time.Hour)}, err: errors.New("unknown category")})
+	if len(m.state.pendingWrites) != 0 || len(m.state.pendingEvents) != 0 || m.state.statusText != "saving failed: unknown category" {
+		t.Errorf("after permanent failure: %d writes, %d events pending, status %q", len(m.state.pendingWrites), len(m.state.pendingEvents), m.state.statusText)
+	}
+}
+
+func Test_updateRecord_inFlight(t *testing.T) {
+	var (
+		db     = &writingDatabase{recordErr: fmt.Errorf("%w: database is locked", ErrTransient)}
+		m      = New(db)
+		t0     = time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+		record = Record{Start: t0, CategoryID: 1}
+	)
+	update := func(msg tea.Msg) tea.Cmd {
+		model, cmd := m.Update(msg)
+		m = model.(MyHours)
+		return cmd
+	}
+	// starting the record fails for now.
+	m.state.activeRecord = record
+	update(m.updateRecord(record)())
+	// the timer is stopped while the retry is in flight.
+	retry := update(retryWritesMsg{})
+	db.recordErr = nil
+	update(timerStopMsg{start: t0, end: t0.Add(time.Hour)})
+	if len(db.writes) != 0 || len(m.state.pendingWrites) != 1 {
+		t.Fatalf("writes = %v, %d pending, want stopped record waiting for the retry", db.writes, len(m.state.pendingWrites))
+	}
+	// once the record is inserted, it's stopped by its ID. The stop goes
+	// first, the rest shows status.
+	stop := update(retry())
+	update(stop().(tea.BatchMsg)[0]())
+	if want := []string{"start", "update 1"}; !slices.Equal(db.writes, want) {
+		t.Errorf("writes = %v, want %v", db.writes, want)
+	}
+	if len(m.state.pendingWrites) != 0 || len(m.state.writing) != 0 || m.state.activeRecord.ID != 1 || m.state.activeRecord.Active() {
+		t.Errorf("%d pending, %d writing, active record %+v, want stopped record 1", len(m.state.pendingWrites), len(m.state.writing), m.state.activeRecord)
+	}
+}
+
+func Test_updateSetting_retry(t *testing.T) {
+	var (
+		db = &writingDatabase{settingErr: fmt.Errorf("%w: database is locked", ErrTransient)}
+		m  = New(db)
+	)
+	update := func(msg tea.Msg) tea.Cmd {
+		model, cmd := m.Update(msg)
+		m = model.(MyHours)
+		return cmd
+	}
+	// the change is applied, and queued for retry.
+	applied := update(m.updateGlobalCategoryID(2)())
+	update(applied().(tea.BatchMsg)[0]())
+	if len(m.state.pendingSettings) != 1 || !m.state.retrying || m.settings.DefaultCategoryID != 2 {
+		t.Fatalf("%d pending, retrying %v, category %d, want change applied and pending", len(m.state.pendingSettings), m.state.retrying, m.settings.DefaultCategoryID)
+	}
+	// changes made while the retry is in flight wait for it.
+	retry := update(retryWritesMsg{})
+	db.settingErr = nil
+	update(m.updateGlobalCategoryID(3)())
+	if len(db.writes) != 0 || m.settings.DefaultCategoryID != 3 {
+		t.Fatalf("writes = %v, category %d, want change applied and waiting", db.writes, m.settings.DefaultCategoryID)
+	}
+	update(update(retry())())
+	if want := []string{"default_category=2", "default_category=3"}; !slices.Equal(db.writes, want) {
+		t.Errorf("writes = %v, want %v", db.writes, want)
+	}
+	if m.unsaved() != 0 || len(m.state.writingSettings) != 0 || m.settings.DefaultCategoryID != 3 {
+		t.Errorf("%d unsaved, %d writing, category %d, want category 3 saved", m.unsaved(), len(m.state.writingSettings), m.settings.DefaultCategoryID)
+	}
+}
+
+func Test_writeFailedMsg_activeRecord(t *testing.T) {
+	var (
+		db     = &writingDatabase{recordErr: errors.New("active record already exists")}
+		m      = New(db)
+		record = Record{Start: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC), CategoryID: 1}
+	)
+	update := func(msg tea.Msg) tea.Cmd {
+		model, cmd := m.Update(msg)
+		m = model.(MyHours)
+		return cmd
+	}
+	// the record of the running timer is kept, even if retrying may not help.
+	m.state.activeRecord = record
+	update(m.updateRecord(record)())
+	for range 2 {
+		if len(m.state.pendingWrites) != 1 || !m.state.retrying || !strings.Contains(m.state.statusText, "active record already exists") {
+			t.Fatalf("%d pending, retrying %v, status %q, want active record pending with error shown", len(m.state.pendingWrites), m.state.retrying, m.state.statusText)
+		}
+		update(update(retryWritesMsg{})())
+	}
+}
+
+func Test_scheduleRetry_backoff(t *testing.T) {
+	var (
+		transient = fmt.Errorf("%w: database is locked", ErrTransient)
+		db        = &writingDatabase{recordErr: transient}
+		m         = New(db)
+		record    = Record{Start: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC), CategoryID: 1}
+		delays    []time.Duration
+	)
+	update := func(msg tea.Msg) tea.Cmd {
+		model, cmd := m.Update(msg)
+		m = model.(MyHours)
+		return cmd
+	}
+	update(writeFailedMsg{record: record, err: transient})
+	for range 10 {
+		delays = append(delays, m.state.retryDelay)
+		update(update(retryWritesMsg{})())
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second,
+		32 * time.Second, 64 * time.Second, 128 * time.Second, 256 * time.Second, maxWriteBackoff}
+	if !slices.Equal(delays, want) {
+		t.Errorf("retry delays = %v, want %v", delays, want)
+	}
+	// a successful write starts over from the first delay.
+	db.recordErr = nil
+	update(update(retryWritesMsg{})())
+	if m.state.retryDelay != 0 || len(m.state.pendingWrites) != 0 {
+		t.Errorf("after success: retry delay %v, %d pending, want none", m.state.retryDelay, len(m.state.pendingWrites))
+	}
+	update(writeFailedMsg{record: Record{Start: record.Start.Add(time.Hour), CategoryID: 1}, err: transient})
+	if m.state.retryDelay != writeBackoff {
+		t.Errorf("retry delay after new failure = %v, want %v", m.state.retryDelay, writeBackoff)
+	}
+}
+
+func Test_splitRecord(t *testing.T) {
+	var (
+		t0     = time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+		record = Record{ID: 1, Start: t0, CategoryID: 1, Notes: "forgot", Breaks: []Break{{Start: t0.Add(time.Hour)}}}
+		at     = t0.Add(2 * time.Hour)
+		now    = t0.Add(5 * time.Hour)
+	)
+	db := &writingDatabase{}
+	msg, ok := New(db).splitRecord(record, at, now)().(recordSwitchedMsg)
+	if !ok {
+		t.Fatal("splitRecord() didn't switch records")
+	}
+	if want := []string{"switch 11:00:00 14:00:00"}; !slices.Equal(db.writes, want) {
+		t.Errorf("writes = %v, want %v", db.writes, want)
+	}
+	if !msg.ended.End.Equal(at) || !msg.ended.Breaks[0].End.Equal(at) {
+		t.Errorf("ended record = %+v, want it and its break ended at %v", msg.ended, at)
+	}
+	if msg.started.ID != 2 || !msg.started.Start.Equal(now) || msg.started.Notes != record.Notes {
+		t.Errorf("started record = %+v, want copy of the record from %v", msg.started, now)
+	}
+	// nothing is ended if the switch fails.
+	db = &writingDatabase{recordErr: errors.New("database is locked")}
+	if msg, ok := New(db).splitRecord(record, at, now)().(errorMsg); !ok {
+		t.Errorf("splitRecord() = %T, want errorMsg", msg)
+	}
+}
+
+func Test_parseTime(t *testing.T) {
+	ref := time.Date(2025, 6, 1, 9, 30, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		input   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "clock time", input: "17:05", want: time.Date(2025, 6, 1, 17, 5, 0, 0, time.Local)},
+		{name: "clock time with seconds", input: " 17:05:30 ", want: time.Date(2025, 6, 1, 17, 5, 30, 0, time.Local)},
+		{name: "date and time", input: "2025-06-02 01:15", want: time.Date(2025, 6, 2, 1, 15, 0, 0, time.Local)},
+		{name: "invalid", input: "5pm", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTime(tt.input, ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTime() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_forgotten(t *testing.T) {
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		record    Record
+		threshold time.Duration
+		want      bool
+	}{
+		{name: "not active", record: Record{Start: now.Add(-48 * time.Hour), End: now}, threshold: time.Hour, want: false},
+		{name: "recent", record: Record{Start: now.Add(-time.Hour)}, threshold: 10 * time.Hour, want: false},
+		{name: "over threshold", record: Record{Start: now.Add(-2 * time.Hour)}, threshold: time.Hour, want: true},
+		{name: "over night", record: Record{Start: now.Add(-10 * time.Hour)}, threshold: 0, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := forgotten(tt.record, now, tt.threshold); got != tt.want {
+				t.Errorf("forgotten() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_timebox_next(t *testing.T) {
+	now := time.Date(2025, 6, 2, 9, 0, 0, 0, time.Local)
+	b := newTimebox(25*time.Minute, now)
+	tests := []struct {
+		name       string
+		wantPhase  timeboxPhase
+		wantLength time.Duration
+	}{
+		{name: "first break", wantPhase: phaseShortBreak, wantLength: shortBreakLength},
+		{name: "back to work", wantPhase: phaseWork, wantLength: 25 * time.Minute},
+		{name: "second break", wantPhase: phaseShortBreak, wantLength: shortBreakLength},
+		{name: "work", wantPhase: phaseWork, wantLength: 25 * time.Minute},
+		{name: "third break", wantPhase: phaseShortBreak, wantLength: shortBreakLength},
+		{name: "work again", wantPhase: phaseWork, wantLength: 25 * time.Minute},
+		{name: "long break", wantPhase: phaseLongBreak, wantLength: longBreakLength},
+	}
+	for _, tt := range tests {
+		now = now.Add(b.length)
+		if !b.expired(now) {
+			t.Fatalf("%s: expired() = false at end of phase", tt.name)
+		}
+		b = b.next(now)
+		if b.phase != tt.wantPhase || b.length != tt.wantLength {
+			t.Errorf("%s: next() = %v %v, want %v %v", tt.name, b.phase, b.length, tt.wantPhase, tt.wantLength)
+		}
+	}
+}
+
+func Test_parseTimebox(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "25m", want: 25 * time.Minute},
+		{in: " 1h30m ", want: 90 * time.Minute},
+		{in: "", want: 0},
+		{in: "0", want: 0},
+		{in: "-5m", wantErr: true},
+		{in: "soon", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseTimebox(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimebox() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTimebox() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseWhen(t *testing.T) {
+	now := time.Date(2025, 6, 2, 9, 30, 0, 0, time.Local)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{in: "15m", want: now.Add(-15 * time.Minute)},
+		{in: "1h5m", want: now.Add(-65 * time.Minute)},
+		{in: "08:45", want: time.Date(2025, 6, 2, 8, 45, 0, 0, time.Local)},
+		{in: "-5m", wantErr: true},
+		{in: "a while ago", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseWhen(tt.in, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseWhen() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseWhen() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateStart(t *testing.T) {
+	now := time.Date(2025, 6, 2, 9, 30, 0, 0, time.Local)
+	prevEnd := now.Add(-time.Hour)
+	tests := []struct {
+		name    string
+		at      time.Time
+		wantErr bool
+	}{
+		{name: "after previous", at: now.Add(-30 * time.Minute)},
+		{name: "at previous end", at: prevEnd},
+		{name: "overlaps previous", at: prevEnd.Add(-time.Minute), wantErr: true},
+		{name: "in the future", at: now.Add(time.Minute), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateStart(tt.at, prevEnd, now); (err != nil) != tt.wantErr {
+				t.Errorf("validateStart() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_confirmPrompt_startAt(t *testing.T) {
+	m := New(&writingDatabase{})
+	m.openStartAtPrompt()
+	m.state.prompt.field.SetValue("30m")
+	// the start can't be checked before the previous record is known.
+	m, _ = m.confirmPrompt()
+	if m.state.prompt.kind != promptStartAt || m.state.prompt.err == "" {
+		t.Fatalf("prompt %v, error %q, want confirm blocked while loading", m.state.prompt.kind, m.state.prompt.err)
+	}
+	model, _ := m.Update(lastRecordMsg{record: Record{End: m.now().Add(-10 * time.Minute)}})
+	m = model.(MyHours)
+	m, _ = m.confirmPrompt()
+	if m.state.prompt.kind != promptStartAt || !strings.Contains(m.state.prompt.err, "overlaps") {
+		t.Errorf("prompt %v, error %q, want overlap with the loaded record", m.state.prompt.kind, m.state.prompt.err)
+	}
+}
+
+func Test_listTasks(t *testing.T) {
+	standup := Task{CategoryID: 3, Notes: "standup"}
+	review := Task{CategoryID: 3, Notes: "PROJ-123 review"}
+	gym := Task{CategoryID: 2, Notes: "gym"}
+	tests := []struct {
+		name   string
+		pinned []Task
+		recent []Task
+		limit  int
+		want   []Task
+	}{
+		{name: "nothing", limit: 9, want: nil},
+		{name: "recent only", recent: []Task{review, standup}, limit: 9, want: []Task{review, standup}},
+		{name: "pinned first", pinned: []Task{gym}, recent: []Task{review, standup}, limit: 9, want: []Task{gym, review, standup}},
+		{name: "no duplicates", pinned: []Task{standup}, recent: []Task{review, standup}, limit: 9, want: []Task{standup, review}},
+		{name: "limited", pinned: []Task{gym}, recent: []Task{review, standup}, limit: 2, want: []Task{gym, review}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := listTasks(tt.pinned, tt.recent, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listTasks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_totals_sum(t *testing.T) {
+	week := time.Date(2025, 6, 2, 0, 0, 0, 0, time.Local)
+	day := week.AddDate(0, 0, 2)
+	tt := totals{
+		day:  day,
+		week: week,
+		records: []Record{
+			{ID: 1, CategoryID: 3, Start: week.Add(9 * time.Hour), End: week.Add(17 * time.Hour)},
+			{ID: 2, CategoryID: 2, Start: day.Add(7 * time.Hour), End: day.Add(8 * time.Hour)},
+			{ID: 3, CategoryID: 3, Start: day.Add(8 * time.Hour), End: day.Add(10 * time.Hour)},
+			// active record is counted from the timer, not from the database.
+			{ID: 4, CategoryID: 3, Start: day.Add(10 * time.Hour), End: day.Add(11 * time.Hour)},
+			// previous week.
+			{ID: 5, CategoryID: 3, Start: week.Add(-time.Hour), End: week.Add(-time.Minute)},
+		},
+	}
+	categories := []Category{{ID: 3, Name: "work"}, {ID: 2, Name: "personal"}}
+	active := Record{ID: 4, CategoryID: 3, Start: day.Add(10 * time.Hour)}
+	rows, total := tt.sum(categories, active, 30*time.Minute)
+	wantRows := []totalsRow{
+		{categoryID: 3, day: 150 * time.Minute, week: 630 * time.Minute},
+		{categoryID: 2, day: time.Hour, week: time.Hour},
+	}
+	if !reflect.DeepEqual(rows, wantRows) {
+		t.Errorf("sum() rows = %v, want %v", rows, wantRows)
+	}
+	wantTotal := totalsRow{day: 210 * time.Minute, week: 690 * time.Minute}
+	if total != wantTotal {
+		t.Errorf("sum() total = %v, want %v", total, wantTotal)
+	}
+}
+
+func Test_sortRows(t *testing.T) {
+	rows := [][]string{
+		{"09:00", "standup", "15m0s"},
+		{"10:00", "review", "1h30m0s"},
+		{"08:00", "email", "45m0s"},
+	}
+	tests := []struct {
+		name   string
+		column int
+		desc   bool
+		want   []string
+	}{
+		{name: "report order", column: 0, want: []string{"09:00", "10:00", "08:00"}},
+		{name: "report order reversed", column: 0, desc: true, want: []string{"08:00", "10:00", "09:00"}},
+		{name: "by time", column: 1, want: []string{"08:00", "09:00", "10:00"}},
+		{name: "by text", column: 2, want: []string{"08:00", "10:00", "09:00"}},
+		{name: "by duration", column: 3, want: []string{"09:00", "08:00", "10:00"}},
+		{name: "by duration descending", column: 3, desc: true, want: []string{"10:00", "08:00", "09:00"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, row := range sortRows(rows, tt.column, tt.desc) {
+				got = append(got, row[0])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sortRows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_reportCursor_scroll(t *testing.T) {
+	tests := []struct {
+		name     string
+		cursor   reportCursor
+		rows     int
+		capacity int
+		want     reportCursor
+	}{
+		{name: "fits", cursor: reportCursor{row: 2}, rows: 5, capacity: 10, want: reportCursor{row: 2}},
+		{name: "below view", cursor: reportCursor{row: 6}, rows: 10, capacity: 5, want: reportCursor{row: 6, offset: 2}},
+		{name: "above view", cursor: reportCursor{row: 1, offset: 3}, rows: 10, capacity: 5, want: reportCursor{row: 1, offset: 1}},
+		{name: "within view", cursor: reportCursor{row: 4, offset: 2}, rows: 10, capacity: 5, want: reportCursor{row: 4, offset: 2}},
+		{name: "past last row", cursor: reportCursor{row: 12, offset: 5}, rows: 10, capacity: 5, want: reportCursor{row: 9, offset: 5}},
+		{name: "before first row", cursor: reportCursor{row: -1}, rows: 10, capacity: 5, want: reportCursor{row: 0}},
+		{name: "no rows", cursor: reportCursor{row: 3, offset: 1}, rows: 0, capacity: 5, want: reportCursor{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cursor.scroll(tt.rows, tt.capacity); got != tt.want {
+				t.Errorf("scroll() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_matchCommands(t *testing.T) {
+	commands := []command{
+		{name: "export", desc: "Export this report to CSV"},
+		{name: "notes", desc: "Set notes of the task"},
+		{name: "start", desc: "Start"},
+		{name: "switch_category", desc: "Switch category"},
+		{name: "week", desc: "Go to week report"},
+	}
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "empty matches all", query: "", want: []string{"export", "notes", "start", "switch_category", "week"}},
+		{name: "exact name first", query: "start", want: []string{"start"}},
+		{name: "word starts", query: "swc", want: []string{"switch_category"}},
+		{name: "description", query: "csv", want: []string{"export"}},
+		{name: "case insensitive", query: "WEEK", want: []string{"week"}},
+		{name: "no match", query: "xyz", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range matchCommands(commands, tt.query) {
+				got = append(got, c.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("matchCommands() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_commands_notes(t *testing.T) {
+	t0 := time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		record Record
+		want   bool
+	}{
+		{name: "no record"},
+		{name: "finished record", record: Record{ID: 1, Start: t0, End: t0.Add(time.Hour)}},
+		{name: "active record", record: Record{ID: 1, Start: t0}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := New(&writingDatabase{})
+			m.state.activeRecord = tt.record
+			got := slices.ContainsFunc(m.commands(), func(c command) bool { return c.name == "notes" })
+			if got != tt.want {
+				t.Errorf("notes offered = %v, want %v", got, tt.want)
+			}
+			model, _ := m.Update(setNotesMsg{notes: "notes"})
+			if status := model.(MyHours).state.statusText; (status == "no active record") == tt.want {
+				t.Errorf("status after setNotesMsg = %q", status)
+			}
+		})
+	}
+}
+
+func Test_keyMsg(t *testing.T) {
+	for _, k := range []string{"s", ":", " ", "right", "esc", "f1", "ctrl+c", "pgdown", "alt+x"} {
+		if got := keyMsg(k).String(); got != k {
+			t.Errorf("keyMsg(%q).String() = %q", k, got)
+		}
+	}
+}
+
+// blockingDatabase blocks report loads until they are cancelled.
+type blockingDatabase struct{ Database }
+
+func (blockingDatabase) RecordsInCategory(ctx context.Context, _, _ time.Time, _ int64) ([]Record, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingDatabase) StartRecord(ctx context.Context, _ time.Time, _ int64, _ string) (int64, error) {
+	<-ctx.Done()
+	return 0, ctx.Err()
+}
+
+func Test_quit_cancel(t *testing.T) {
+	m := New(blockingDatabase{})
+	write := m.updateRecord(Record{Start: time.Date(2025, 6, 1, 9, 0, 0, 0, time.UTC), CategoryID: 1})
+	res := make(chan tea.Msg)
+	go func() { res <- write() }()
+	// quitting cancels the writes in progress.
+	if _, cmd := m.Update(keyMsg("q")); cmd == nil {
+		t.Fatal("Update() returned no command for quit")
+	}
+	select {
+	case msg := <-res:
+		if failed, ok := msg.(writeFailedMsg); !ok || !errors.Is(failed.err, context.Canceled) {
+			t.Errorf("cancelled write returned %v, want writeFailedMsg with context.Canceled", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("write was not cancelled")
+	}
+}
+
+func Test_updateReportData_cancel(t *testing.T) {
+	m := New(blockingDatabase{})
+	m.state.activeView = 1
+	load := m.updateReportData()
+	res := make(chan tea.Msg)
+	go func() { res <- load() }()
+	// changing the view cancels the load in progress.
+	m.state.activeView = 0
+	if cmd := m.updateReportData(); cmd != nil {
+		t.Errorf("updateReportData() = %v, want nil for timer view", cmd)
+	}
+	select {
+	case msg := <-res:
+		if msg != nil {
+			t.Errorf("cancelled load returned %v, want nil", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("load was not cancelled")
+	}
+}
+
+// countingDatabase counts reads of records, and fails writes.
+func Test_prefetchReports_cancel(t *testing.T) {
+	m := New(blockingDatabase{})
+	m.state.activeView = 1
+	// with the page in view cached, only the page before it is loaded.
+	from, _ := reportDaily.dates(m.reportNow(), 0)
+	key := reportKey{viewID: 1, categoryID: m.settings.DefaultCategoryID}
+	m.state.reportCache.put(m.state.reportCache.generation, key, reportCacheEntry{from: from})
+	prefetch := m.updateReportData()
+	if prefetch == nil || m.state.reportLoading {
+		t.Fatal("updateReportData() of cached page, want only prefetch")
+	}
+	res := make(chan tea.Msg)
+	go func() { res <- prefetch() }()
+	// changing the view cancels the prefetch, so it can be started again.
+	m.state.activeView = 0
+	m.updateReportData()
+	select {
+	case msg := <-res:
+		if msg != nil {
+			t.Errorf("cancelled prefetch returned %v, want nil", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("prefetch was not cancelled")
+	}
+	if len(m.state.reportCache.pending) != 0 {
+		t.Errorf("pending = %v after cancel, want none", m.state.reportCache.pending)
+	}
+}
+
+type countingDatabase struct {
+	Database
+	reads int
+}
+
+func (db *countingDatabase) RecordsInCategory(_ context.Context, from, _ time.Time, categoryID int64) ([]Record, error) {
+	db.reads++
+	return []Record{{ID: 1, Start: from, CategoryID: categoryID, Breaks: []Break{{Start: from}}}}, nil
+}
+
+func (db *countingDatabase) Aggregate(_ context.Context, q AggregateQuery) ([]Aggregate, error) {
+	db.reads++
+	return []Aggregate{{Start: q.From, Records: 1}}, nil
+}
+
+func (db *countingDatabase) StartRecord(context.Context, time.Time, int64, string) (int64, error) {
+	return 0, errors.New("failed")
+}
+
+func Test_CachingDatabase(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		backend = &countingDatabase{}
+		db      = CachingDatabase(backend)
+		from    = time.Date(2026, 1, 5, 0, 0, 0, 0, time.Local)
+		before  = from.AddDate(0, 0, 7)
+	)
+	read := func(categoryID int64) []Record {
+		t.Helper()
+		records, err := db.RecordsInCategory(ctx, from, before, categoryID)
+		if err != nil {
+			t.Fatalf("RecordsInCategory() error = %v", err)
+		}
+		return records
+	}
+	// modifying returned records must not modify the cache.
+	read(1)[0].Breaks[0].End = before
+	if got := read(1); backend.reads != 1 || !got[0].Breaks[0].End.IsZero() {
+		t.Errorf("cached read: reads = %d, breaks = %v, want 1 read and unmodified breaks", backend.reads, got[0].Breaks)
+	}
+	read(2)
+	if backend.reads != 2 {
+		t.Errorf("other category: reads = %d, want 2", backend.reads)
+	}
+	// writes invalidate, failed or not.
+	_, _ = db.StartRecord(ctx, before, 1, "")
+	read(1)
+	if backend.reads != 3 {
+		t.Errorf("after write: reads = %d, want 3", backend.reads)
+	}
+	// aggregates are cached by the whole query.
+	for _, q := range []AggregateQuery{
+		{From: from, Before: before, Period: PeriodDay},
+		{From: from, Before: before, Period: PeriodDay},
+		{From: from, Before: before, Period: PeriodWeek},
+	} {
+		if _, err := db.Aggregate(ctx, q); err != nil {
+			t.Fatalf("Aggregate() error = %v", err)
+		}
+	}
+	if backend.reads != 5 {
+		t.Errorf("aggregates: reads = %d, want 5", backend.reads)
+	}
+	// only the most recently used results are kept.
+	for categoryID := range int64(cachedQueries) {
+		read(categoryID + 10)
+	}
+	read(cachedQueries + 9)
+	if backend.reads != 5+cachedQueries {
+		t.Errorf("recent read: reads = %d, want %d", backend.reads, 5+cachedQueries)
+	}
+	read(1)
+	if backend.reads != 6+cachedQueries {
+		t.Errorf("evicted read: reads = %d, want %d", backend.reads, 6+cachedQueries)
+	}
+}
+
+func Test_reportCache(t *testing.T) {
+	var (
+		from  = time.Date(2026, 1, 5, 0, 0, 0, 0, time.Local)
+		key   = func(pageNo int) reportKey { return reportKey{viewID: 1, pageNo: pageNo, categoryID: 2} }
+		entry = reportCacheEntry{from: from, table: reportTable{title: "Day"}}
+		// fill puts pages from given page on, until the cache is full.
+		fill = func(c *reportCache, pageNo int) {
+			for i := range reportCacheSize {
+				c.put(c.generation, key(pageNo-i), entry)
+			}
+		}
+	)
+	tests := []struct {
+		name  string
+		setup func(c *reportCache)
+		from  time.Time
+		opts  reportOptions
+		want  bool
+	}{
+		{name: "cached", setup: func(c *reportCache) { c.put(0, key(0), entry) }, from: from, want: true},
+		{name: "other date", setup: func(c *reportCache) { c.put(0, key(0), entry) }, from: from.AddDate(0, 0, 1)},
+		{name: "other options", setup: func(c *reportCache) { c.put(0, key(0), entry) }, from: from, opts: reportOptions{showBreaks: true}},
+		{name: "evicted", setup: func(c *reportCache) { fill(c, 0); fill(c, -1) }, from: from},
+		{name: "recently used kept", setup: func(c *reportCache) {
+			fill(c, 0)
+			c.get(key(0), from, reportOptions{})
+			c.put(0, key(-reportCacheSize), entry)
+		}, from: from, want: true},
+		{name: "invalidated", setup: func(c *reportCache) { c.put(0, key(0), entry); c.invalidate() }, from: from},
+		{name: "loaded before invalidation", setup: func(c *reportCache) { c.invalidate(); c.put(0, key(0), entry) }, from: from},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newReportCache()
+			tt.setup(c)
+			if _, got := c.get(key(0), tt.from, tt.opts); got != tt.want {
+				t.Errorf("get() = %v, want %v", got, tt.want)
+			}
+			if len(c.pages.entries) > reportCacheSize || len(c.pages.recent) != len(c.pages.entries) {
+				t.Errorf("%d entries, %d recent, want at most %d of both", len(c.pages.entries), len(c.pages.recent), reportCacheSize)
+			}
+		})
+	}
+}
+
+func Test_updateReportData_cache(t *testing.T) {
+	var (
+		db = &countingDatabase{}
+		m  = New(db, UseClock(NewFakeClock(time.Date(2026, 1, 7, 10, 30, 0, 0, time.Local))))
+	)
+	// tabs are enabled by timer init.
+	m.keys.nextTab.SetEnabled(true)
+	m.keys.prevTab.SetEnabled(true)
+	// run the command and the commands returned from handling its messages.
+	var run func(cmd tea.Cmd)
+	run = func(cmd tea.Cmd) {
+		if cmd == nil {
+			return
+		}
+		switch msg := cmd().(type) {
+		case tea.BatchMsg:
+			for _, cmd := range msg {
+				run(cmd)
+			}
+		case nil:
+		default:
+			model, cmd := m.Update(msg)
+			m = model.(MyHours)
+			run(cmd)
+		}
+	}
+	press := func(k string) {
+		model, cmd := m.Update(keyMsg(k))
+		m = model.(MyHours)
+		run(cmd)
+	}
+	tests := []struct {
+		name      string
+		key       string
+		wantReads int
+	}{
+		// page in view, and the previous page.
+		{name: "day view", key: "l", wantReads: 2},
+		// from cache, and the page before it.
+		{name: "previous page", key: "k", wantReads: 3},
+		{name: "back to latest page", key: "j", wantReads: 3},
+		// the day view comes back from cache, but the week is loaded.
+		{name: "week view", key: "l", wantReads: 5},
+		{name: "back to day view", key: "h", wantReads: 5},
+	}
+	for _, tt := range tests {
+		press(tt.key)
+		if db.reads != tt.wantReads {
+			t.Errorf("%s: reads = %d, want %d", tt.name, db.reads, tt.wantReads)
+		}
+		if m.state.reportLoading {
+			t.Errorf("%s: report still loading", tt.name)
+		}
+	}
+	// changed records are loaded again, along with both pages next to it.
+	model, _ := m.Update(updateRecordMsg{})
+	m = model.(MyHours)
+	press("k")
+	if db.reads != 8 {
+		t.Errorf("after record update: reads = %d, want 8", db.reads)
+	}
+}
+
+// errImportFailed is returned from importingDatabase.
+var errImportFailed = errors.New("import failed")
+
+// importingDatabase counts records imported per batch, failing the import of
+// the batch numbered fail.
+type importingDatabase struct {
+	Database
+	batches []int
+	fail    int
+}
+
+func (db *importingDatabase) ImportRecords(_ context.Context, records []Record) ([]int64, error) {
+	if len(db.batches)+1 == db.fail {
+		return nil, errImportFailed
+	}
+	db.batches = append(db.batches, len(records))
+	return make([]int64, len(records)), nil
+}
+
+func Test_ImportRecordsSeq(t *testing.T) {
+	errRead := errors.New("read failed")
+	// records yields n records, followed by err if set.
+	records := func(n int, err error) iter.Seq2[Record, error] {
+		return func(yield func(Record, error) bool) {
+			for range n {
+				if !yield(Record{}, nil) {
+					return
+				}
+			}
+			if err != nil {
+				yield(Record{}, err)
+			}
+		}
+	}
+	tests := []struct {
+		name         string
+		records      iter.Seq2[Record, error]
+		batchSize    int
+		fail         int
+		wantImported int
+		wantProgress []int
+		wantErr      error
+	}{
+		{name: "batches", records: records(5, nil), batchSize: 2, wantImported: 5, wantProgress: []int{2, 4, 5}},
+		{name: "full batches", records: records(4, nil), batchSize: 2, wantImported: 4, wantProgress: []int{2, 4}},
+		{name: "no records", records: records(0, nil), batchSize: 2},
+		{name: "no batch size", records: records(2, nil), wantImported: 2, wantProgress: []int{1, 2}},
+		{name: "read fails", records: records(3, errRead), batchSize: 2, wantImported: 2, wantProgress: []int{2}, wantErr: errRead},
+		{name: "import fails", records: records(5, nil), batchSize: 2, fail: 2, wantImported: 2, wantProgress: []int{2}, wantErr: errImportFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				db       = &importingDatabase{fail: tt.fail}
+				progress []int
+			)
+			imported, err := ImportRecordsSeq(context.Background(), db, tt.records, tt.batchSize, func(n int) {
+				progress = append(progress, n)
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ImportRecordsSeq() error = %v, want %v", err, tt.wantErr)
+			}
+			if imported != tt.wantImported || !slices.Equal(progress, tt.wantProgress) {
+				t.Errorf("ImportRecordsSeq() = %d, progress %v, want %d, progress %v", imported, progress, tt.wantImported, tt.wantProgress)
+			}
+		})
+	}
+}
+
+func Test_TimingDatabase(t *testing.T) {
+	var methods []string
+	db := TimingDatabase(&countingDatabase{}, func(method string, _ time.Duration) {
+		methods = append(methods, method)
+	})
+	_, _ = db.RecordsInCategory(context.Background(), time.Now(), time.Now(), 1)
+	_, _ = db.StartRecord(context.Background(), time.Now(), 1, "")
+	if want := []string{"RecordsInCategory", "StartRecord"}; !reflect.DeepEqual(methods, want) {
+		t.Errorf("timed methods = %v, want %v", methods, want)
+	}
+}
+
+func Test_reportDates(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		t.Skipf("time zone not available: %v", err)
+	}
+	local := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = local })
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, loc) }
+	// clocks move forward on 2026-03-29.
+	now := time.Date(2026, 3, 30, 0, 30, 0, 0, loc)
+	tests := []struct {
+		name       string
+		dates      reportDatesFunc
+		page       int
+		from, till time.Time
+	}{
+		{name: "day", dates: reportDatesDaily, page: 0, from: date(2026, 3, 30), till: date(2026, 3, 31)},
+		{name: "day of DST change", dates: reportDatesDaily, page: -1, from: date(2026, 3, 29), till: date(2026, 3, 30)},
+		{name: "week of DST change", dates: reportDatesWeekly, page: -1, from: date(2026, 3, 23), till: date(2026, 3, 30)},
+		{name: "month", dates: reportDatesMonthly, page: -1, from: date(2026, 2, 1), till: date(2026, 3, 1)},
+		{name: "year", dates: reportDatesYearly, page: -1, from: date(2025, 1, 1), till: date(2026, 1, 1)},
+		{name: "future page is latest", dates: reportDatesWeekly, page: 2, from: date(2026, 3, 30), till: date(2026, 4, 6)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, till := tt.dates(now, tt.page)
+			if !from.Equal(tt.from) || !till.Equal(tt.till) {
+				t.Errorf("dates() = %v – %v, want %v – %v", from, till, tt.from, tt.till)
+			}
+		})
+	}
+}
+
+func Test_timer_clock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2026, 1, 5, 9, 0, 0, 0, time.Local))
+	tm := newTimer(time.Millisecond, clock)
+	tm, _ = tm.restart(clock.Now())
+	clock.Advance(30 * time.Minute)
+	tm, _ = tm.update(timerPauseMsg{at: clock.Now()})
+	clock.Advance(time.Hour)
+	if got := tm.view(glyphsPlain); got != glyphsPlain.paused+"30m0s" {
+		t.Errorf("paused view() = %q, want 30m0s", got)
+	}
+	tm, _ = tm.update(timerResumeMsg{at: clock.Now()})
+	clock.Advance(15 * time.Minute)
+	if got := tm.elapsed(clock.Now()); got != 45*time.Minute {
+		t.Errorf("elapsed() = %v, want 45m", got)
+	}
+	// a jump in the clock between ticks is reported.
+	tm, _ = tm.update(timerTickMsg{tag: tm.tag})
+	clock.Advance(2 * time.Hour)
+	_, cmd := tm.update(timerTickMsg{tag: tm.tag})
+	batch, _ := cmd().(tea.BatchMsg)
+	var jump timerJumpMsg
+	for _, cmd := range batch {
+		if msg, ok := cmd().(timerJumpMsg); ok {
+			jump = msg
+		}
+	}
+	if jump.to.Sub(jump.from) != 2*time.Hour {
+		t.Errorf("tick after clock jump = %+v, want jump of 2h", jump)
+	}
+}
+
+func Test_parseReportPage(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		input   string
+		dates   reportDatesFunc
+		want    int
+		wantErr bool
+	}{
+		{name: "empty", input: "", dates: reportDatesWeekly, want: 0},
+		{name: "pages back", input: "-2", dates: reportDatesWeekly, want: -2},
+		{name: "positive is back too", input: "3", dates: reportDatesMonthly, want: -3},
+		{name: "today", input: now.Format(time.DateOnly), dates: reportDatesDaily, want: 0},
+		{name: "week ago", input: now.AddDate(0, 0, -7).Format(time.DateOnly), dates: reportDatesWeekly, want: -1},
+		{name: "future", input: now.AddDate(0, 0, 2).Format(time.DateOnly), dates: reportDatesDaily, wantErr: true},
+		{name: "invalid", input: "soon", dates: reportDatesDaily, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReportPage(tt.input, tt.dates, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseReportPage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseReportPage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_Settings_Set(t *testing.T) {
+	categories := []Category{{ID: 1, Name: "Work"}, {ID: 2, Name: "Personal"}}
+	tests := []struct {
+		name       string
+		key        Setting
+		input      string
+		categories []Category
+		want       string
+		wantErr    bool
+	}{
+		{name: "category", key: SettingDefaultCategory, input: "2", categories: categories, want: "2"},
+		{name: "unknown category", key: SettingDefaultCategory, input: "3", categories: categories, wantErr: true},
+		{name: "category not checked", key: SettingDefaultCategory, input: "3", want: "3"},
+		{name: "category name", key: SettingDefaultCategory, input: "Work", categories: categories, wantErr: true},
+		{name: "duration", key: SettingForgottenTimerThreshold, input: "90m", want: "1h30m0s"},
+		{name: "negative duration", key: SettingForgottenTimerThreshold, input: "-1h", wantErr: true},
+		{name: "bool", key: SettingReportShowBreaks, input: "1", want: "true"},
+		{name: "invalid bool", key: SettingReportShowBreaks, input: "maybe", wantErr: true},
+		{name: "tasks", key: SettingPinnedTasks, input: `[{"category":2,"notes":"x"}]`, categories: categories, want: `[{"category":2,"notes":"x"}]`},
+		{name: "no tasks", key: SettingPinnedTasks, input: `null`, want: `[]`},
+		{name: "task in unknown category", key: SettingPinnedTasks, input: `[{"category":5}]`, categories: categories, wantErr: true},
+		{name: "invalid tasks", key: SettingPinnedTasks, input: `{`, wantErr: true},
+		{name: "unknown setting", key: "week_start", input: "monday", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := DefaultSettings().Set(tt.key, tt.input, tt.categories)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Set() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Set() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_ParseSettings(t *testing.T) {
+	t.Run("defaults are valid", func(t *testing.T) {
+		for _, spec := range SettingSpecs() {
+			if err := ValidateSetting(spec.Key, spec.Default); err != nil {
+				t.Errorf("default of %s: %v", spec.Key, err)
+			}
+		}
+	})
+	t.Run("stored values", func(t *testing.T) {
+		got, err := ParseSettings(map[string]string{
+			"report_show_breaks": "true",
+			"week_start":         "monday",
+		})
+		if err != nil {
+			t.Fatalf("ParseSettings() error = %v", err)
+		}
+		want := DefaultSettings()
+		want.ReportShowBreaks = true
+		want.Unknown = map[string]string{"week_start": "monday"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseSettings() = %+v, want %+v", got, want)
+		}
+	})
+	t.Run("invalid value", func(t *testing.T) {
+		if _, err := ParseSettings(map[string]string{"default_category": "work"}); err == nil {
+			t.Error("ParseSettings() expected an error")
+		}
+	})
+}
+
+// fuzzRecordSets runs check for records decoded from fuzz data, in a time zone
+// with daylight saving time. Seeds are records on edge dates, days counted from
+// 2025-12-01.
+func fuzzRecordSets(f *testing.F, check func(t *testing.T, base time.Time, records []Record)) {
+	loc, err := time.LoadLocation("Europe/Helsinki")
+	if err != nil {
+		f.Skipf("time zone not available: %v", err)
+	}
+	local := time.Local
+	time.Local = loc
+	f.Cleanup(func() { time.Local = local })
+	seeds := [][]uint16{
+		{62},       // 2026-02-01, month starts on Sunday
+		{273},      // 2026-08-31, month ends on Monday
+		{395, 396}, // 2026-12-31 and 2027-01-01, ISO week 53 of 2026
+		{118},      // 2026-03-29, clocks move forward
+		{0, 31, 31, 59, 394, 397, 760},
+	}
+	for _, days := range seeds {
+		var data []byte
+		for _, d := range days {
+			// at 23:30, an hour long with a ten minute break.
+			data = append(data, byte(d>>8), byte(d), byte(1410>>8), byte(1410&0xff), 12, 10)
+		}
+		f.Add(uint8(25), data)
+	}
+	f.Fuzz(func(t *testing.T, year uint8, data []byte) {
+		base := time.Date(2000+int(year%50), 12, 1, 0, 0, 0, 0, time.Local)
+		check(t, base, fuzzRecords(base, data))
+	})
+}
+
+// fuzzRecords decodes records from fuzz data, six bytes each: days and minutes
+// from base, length in five minutes, and break length in minutes. Records are
+// ordered by start, like the database returns them.
+func fuzzRecords(base time.Time, data []byte) []Record {
+	var records []Record
+	for i := 0; i+6 <= len(data); i += 6 {
+		days := int(binary.BigEndian.Uint16(data[i:])) % 800
+		minutes := time.Duration(binary.BigEndian.Uint16(data[i+2:])%1440) * time.Minute
+		start := base.AddDate(0, 0, days).Add(minutes)
+		record := Record{
+			ID:         int64(len(records) + 1),
+			Start:      start,
+			End:        start.Add(time.Duration(data[i+4]) * 5 * time.Minute),
+			CategoryID: int64(data[i+4]%3 + 1),
+			Notes:      "task",
+		}
+		if b := time.Duration(data[i+5]) * time.Minute; b < record.End.Sub(record.Start) {
+			record.Breaks = []Break{{Start: start, End: start.Add(b)}}
+		}
+		records = append(records, record)
+	}
+	slices.SortStableFunc(records, func(a, b Record) int { return a.Start.Compare(b.Start) })
+	return records
+}
+
+// checkPeriods checks that periods cover the range of the query without gaps,
+// and each is within a single calendar period.
+func checkPeriods(t *testing.T, q AggregateQuery) []time.Time {
+	t.Helper()
+	bounds := q.Periods()
+	if !bounds[0].Equal(q.From) || !bounds[len(bounds)-1].Equal(q.Before) {
+		t.Fatalf("Periods() = %v – %v, want %v – %v", bounds[0], bounds[len(bounds)-1], q.From, q.Before)
+	}
+	for i, start := range bounds[:len(bounds)-1] {
+		end := bounds[i+1]
+		if !start.Before(end) {
+			t.Fatalf("period %d: %v – %v is empty", i, start, end)
+		}
+		if next := q.Period.next(start); !next.IsZero() && end.After(next) {
+			t.Errorf("period %d: %v – %v spans over %v", i, start, end, next)
+		}
+		if i == 0 {
+			continue
+		}
+		local := start.In(time.Local)
+		y, m, d := local.Date()
+		if !local.Equal(time.Date(y, m, d, 0, 0, 0, 0, time.Local)) ||
+			(q.Period == PeriodWeek && local.Weekday() != time.Monday) ||
+			(q.Period == PeriodMonth && d != 1) ||
+			(q.Period == PeriodYear && (m != time.January || d != 1)) {
+			t.Errorf("period %d of %d starts at %v", i, q.Period, local)
+		}
+	}
+	return bounds
+}
+
+// sums returns the durations of aggregates summed by the start of the period
+// of bounds they are in.
+func sums(aggregates []Aggregate, bounds []time.Time) map[int64]time.Duration {
+	res := make(map[int64]time.Duration)
+	for _, a := range aggregates {
+		i := sort.Search(len(bounds), func(i int) bool { return bounds[i].After(a.Start) }) - 1
+		res[bounds[i].UnixNano()] += a.Duration
+	}
+	return res
+}
+
+func FuzzAggregateRecords(f *testing.F) {
+	fuzzRecordSets(f, func(t *testing.T, base time.Time, records []Record) {
+		var (
+			from, before = base.AddDate(0, 0, 17), base.AddDate(0, 0, 790)
+			want         time.Duration
+		)
+		for _, record := range records {
+			if !record.Start.Before(from) && record.Start.Before(before) {
+				want += record.Duration()
+			}
+		}
+		days := AggregateRecords(records, AggregateQuery{From: from, Before: before, Period: PeriodDay})
+		for _, period := range []Period{PeriodDay, PeriodWeek, PeriodMonth, PeriodYear, PeriodAll} {
+			q := AggregateQuery{From: from, Before: before, Period: period}
+			bounds := checkPeriods(t, q)
+			aggregates := AggregateRecords(records, q)
+			// days add up to the totals of longer periods.
+			if got, want := sums(days, bounds), sums(aggregates, bounds); !maps.Equal(got, want) {
+				t.Errorf("period %d: days sum up to %v, want %v", period, got, want)
+			}
+			q.ByCategory = true
+			if got, want := sums(AggregateRecords(records, q), bounds), sums(aggregates, bounds); !maps.Equal(got, want) {
+				t.Errorf("period %d: categories sum up to %v, want %v", period, got, want)
+			}
+			var total time.Duration
+			for _, a := range aggregates {
+				total += a.Duration
+			}
+			if total != want {
+				t.Errorf("period %d: total %v, want %v", period, total, want)
+			}
+		}
+	})
+}
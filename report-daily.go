@@ -0,0 +1,89 @@
+package myhours
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// reportDaily defines a report listing the records of a single day.
+var reportDaily = report{
+	headers: reportHeadersDaily,
+	title:   reportTitleDaily,
+	dates:   reportDatesDaily,
+	styles:  reportStyleDaily,
+	rows:    reportRowsDaily,
+}
+
+func reportHeadersDaily(opts reportOptions) []string {
+	if opts.showBreaks {
+		return []string{"Start", "End", "Notes", "Pomodoros", "Duration", "Breaks"}
+	}
+	return []string{"Start", "End", "Notes", "Pomodoros", "Duration"}
+}
+
+func reportTitleDaily(now time.Time, page int) string {
+	from, _ := reportDatesDaily(now, page)
+	return from.Format("Monday, " + time.DateOnly)
+}
+
+func reportDatesDaily(now time.Time, offset int) (time.Time, time.Time) {
+	if offset > 0 {
+		offset = 0
+	}
+	y, m, d := now.Date()
+	from := time.Date(y, m, d, 0, 0, 0, 0, time.Local).AddDate(0, 0, offset)
+	return from, from.AddDate(0, 0, 1)
+}
+
+func reportStyleDaily(s styles, r, _ int, data []string) lipgloss.Style {
+	if r < 0 || len(data) == 0 || data[0] != "Total" {
+		return s.tableCell
+	}
+	return s.tableSumRow
+}
+
+// reportRowsDaily lists the finished records of the day. Unlike the other
+// reports, the rows are records instead of aggregates of them.
+func reportRowsDaily(ctx context.Context, db RecordStore, from, before time.Time, categoryID int64, opts reportOptions) ([][]string, error) {
+	records, err := db.RecordsInCategory(ctx, from, before, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("db.RecordsInCategory: %w", err)
+	}
+	records = slices.DeleteFunc(records, func(record Record) bool { return !record.Finished() })
+	var (
+		rows      [][]string
+		total     time.Duration
+		breaks    time.Duration
+		pomodoros int
+	)
+	for _, record := range records {
+		d, b := record.Duration(), record.BreakDuration(record.End)
+		total += d
+		breaks += b
+		pomodoros += record.Pomodoros
+		row := []string{
+			record.Start.In(time.Local).Format("15:04"),
+			record.End.In(time.Local).Format("15:04"),
+			record.Notes,
+			strconv.Itoa(record.Pomodoros),
+			d.Truncate(time.Second).String(),
+		}
+		if opts.showBreaks {
+			row = append(row, b.Truncate(time.Second).String())
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return [][]string{noDataRow(len(reportHeadersDaily(opts)))}, nil
+	}
+	row := []string{"Total", "", "", strconv.Itoa(pomodoros), total.Truncate(time.Second).String()}
+	if opts.showBreaks {
+		row = append(row, breaks.Truncate(time.Second).String())
+	}
+	return append(rows, row), nil
+}
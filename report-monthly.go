@@ -1,6 +1,7 @@
 package myhours
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"time"
@@ -14,15 +15,18 @@ var reportMonthly = report{
 	title:   reportTitleMonthly,
 	dates:   reportDatesMonthly,
 	styles:  reportStyleMonthly,
-	mapper:  reportRecordsMonthly,
+	rows:    reportRowsMonthly,
 }
 
-func reportHeadersMonthly() []string {
+func reportHeadersMonthly(opts reportOptions) []string {
+	if opts.showBreaks {
+		return []string{"Week", "Dates", "Duration", "Breaks"}
+	}
 	return []string{"Week", "Dates", "Duration"}
 }
 
-func reportTitleMonthly(page int) string {
-	from, until := reportDatesMonthly(page)
+func reportTitleMonthly(now time.Time, page int) string {
+	from, until := reportDatesMonthly(now, page)
 	return fmt.Sprintf("%s, %d (%s – %s)",
 		from.Month().String(),
 		from.Year(),
@@ -31,11 +35,10 @@ func reportTitleMonthly(page int) string {
 	)
 }
 
-func reportDatesMonthly(offset int) (time.Time, time.Time) {
+func reportDatesMonthly(now time.Time, offset int) (time.Time, time.Time) {
 	if offset > 0 {
 		offset = 0
 	}
-	now := time.Now()
 	y, m, _ := now.Date()
 	// first day of current month, minus as many months as offset says
 	from := time.Date(y, m, 1, 0, 0, 0, 0, time.Local).AddDate(0, offset, 0)
@@ -43,139 +46,47 @@ func reportDatesMonthly(offset int) (time.Time, time.Time) {
 	return from, before
 }
 
-func reportStyleMonthly(r, _ int, data []string) lipgloss.Style {
+func reportStyleMonthly(s styles, r, _ int, data []string) lipgloss.Style {
 	if r < 0 || len(data) == 0 || data[0] == "" || data[0][0] != 'T' {
-		return styleTableCell
+		return s.tableCell
 	}
-	return styleTableSumRow
+	return s.tableSumRow
 }
 
-func reportRecordsMonthly(records []Record) [][]string {
-	var rows [][]string
-	for _, m := range newMonthlySummary(records) {
-		for _, w := range m.weeks {
-			fd, ld := w.dateRange()
-			rows = append(rows, []string{
-				"W" + strconv.Itoa(w.weekNo),
-				fd + " – " + ld,
-				w.total.Truncate(time.Second).String(),
-			})
-		}
-		rows = append(rows, []string{
-			"Total",
-			"",
-			m.total.Truncate(time.Second).String(),
-		})
+// reportRowsMonthly has a row for each ISO week of the month, from records
+// aggregated by week. Weeks are cut at month boundaries.
+func reportRowsMonthly(ctx context.Context, db RecordStore, from, before time.Time, categoryID int64, opts reportOptions) ([][]string, error) {
+	q := AggregateQuery{From: from, Before: before, CategoryID: categoryID, Period: PeriodWeek}
+	weeks, err := db.Aggregate(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("db.Aggregate: %w", err)
 	}
-	if len(rows) == 0 {
-		rows = append(rows, []string{"NO DATA", "NO DATA", "NO DATA"})
-	}
-	return rows
-}
-
-type monthlySummary struct {
-	year      int
-	month     time.Month
-	firstDate string
-	lastDate  string
-	total     time.Duration
-	weeks     []weeklySummary
-}
-
-func (s monthlySummary) activeDays() int {
-	active := 0
-	for _, w := range s.weeks {
-		for _, d := range w.days {
-			if d.total > 0 {
-				active++
-			}
-		}
+	if len(weeks) == 0 {
+		return [][]string{noDataRow(len(reportHeadersMonthly(opts)))}, nil
 	}
-	return active
-}
-
-func newMonthlySummary(records []Record) []monthlySummary {
 	var (
-		months []monthlySummary
-		cm     *monthlySummary
-		cw     *weeklySummary
+		rows   [][]string
+		total  Aggregate
+		bounds = q.Periods()
 	)
-	for _, record := range records {
-		start := record.Start.In(time.Local)
-		y, m, _ := start.Date()
-		if cm == nil || cm.month != m || cm.year != y {
-			first := time.Date(y, m, 1, 0, 0, 0, 0, time.Local)
-			last := time.Date(y, m+1, -1, 0, 0, 0, 0, time.Local)
-			months = append(months, monthlySummary{
-				year:      y,
-				month:     m,
-				firstDate: first.Format(time.DateOnly),
-				lastDate:  last.Format(time.DateOnly),
-			})
-			cm = &months[len(months)-1]
-			// create the month weeks
-			prevWeekNo := 0
-			for !first.After(last) {
-				if _, weekNo := first.ISOWeek(); prevWeekNo < weekNo {
-					prevWeekNo = weekNo
-					wr := weeklySummary{
-						year:     y,
-						weekNo:   weekNo,
-						startsOn: first.Weekday(),
-					}
-					// seed the days of the week to get a full week.
-					dd := first
-					_, wNo := dd.ISOWeek()
-					for wNo == weekNo && dd.Month() == m {
-						wr.days = append(wr.days, dailySummary{
-							date:    dd.Format(time.DateOnly),
-							weekDay: dd.Weekday(),
-							month:   dd.Month(),
-						})
-						dd = dd.AddDate(0, 0, 1)
-						_, wNo = dd.ISOWeek()
-					}
-					cm.weeks = append(cm.weeks, wr)
-				}
-				first = first.AddDate(0, 0, 1)
-			}
-			cw = nil
-		}
-		_, weekNo := record.Start.In(time.Local).ISOWeek()
-		if cw == nil || cw.weekNo != weekNo {
-			for i, w := range cm.weeks {
-				if w.weekNo == weekNo {
-					cw = &cm.weeks[i]
-				}
-			}
-			if cw == nil {
-				panic("week not set somehow!")
-			}
-		}
-		d := record.Duration()
-		cm.total += d
-		cw.total += d
-		date := start.Format(time.DateOnly)
-		for i := range cw.days {
-			if cw.days[i].date != date {
-				continue
-			}
-			cw.days[i].total += d
-			break
+	for i, start := range bounds[:len(bounds)-1] {
+		week := aggregate(weeks, start)
+		total.Duration += week.Duration
+		total.Breaks += week.Breaks
+		_, weekNo := start.ISOWeek()
+		row := []string{
+			"W" + strconv.Itoa(weekNo),
+			start.Format(time.DateOnly) + " – " + bounds[i+1].AddDate(0, 0, -1).Format(time.DateOnly),
+			week.Duration.Truncate(time.Second).String(),
 		}
-		wd := start.Weekday()
-		if wd == 0 {
-			wd = 7
-		}
-		if cw.startsOn == time.Sunday {
-			wd = -7
-		} else {
-			wd -= cw.startsOn
-		}
-		cw.days[wd].total += d
-		if record.Notes != "" {
-			cw.days[wd].notes = append(cw.days[wd].notes, record.Notes)
+		if opts.showBreaks {
+			row = append(row, week.Breaks.Truncate(time.Second).String())
 		}
+		rows = append(rows, row)
+	}
+	row := []string{"Total", "", total.Duration.Truncate(time.Second).String()}
+	if opts.showBreaks {
+		row = append(row, total.Breaks.Truncate(time.Second).String())
 	}
-	return months
+	return append(rows, row), nil
 }
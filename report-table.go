@@ -0,0 +1,112 @@
+package myhours
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportCursor is the scrolling and sorting state of a report table.
+type reportCursor struct {
+	// row is the index of the selected row, among rows that are not totals.
+	row int
+	// offset is the index of the first visible row, among rows that are not
+	// totals.
+	offset int
+	// sortBy is the number of the column rows are sorted by, starting from one.
+	// Zero keeps the rows in report order.
+	sortBy int
+	// sortDesc reverses the sort order.
+	sortDesc bool
+}
+
+// isTotalRow returns true if the row is a totals row. Totals rows are not
+// sorted, and stay visible when the rows are scrolled.
+func isTotalRow(row []string) bool {
+	return len(row) > 0 && row[0] == "Total"
+}
+
+// splitTotals splits the rows into data rows and totals rows.
+func splitTotals(rows [][]string) ([][]string, [][]string) {
+	var data, totals [][]string
+	for _, row := range rows {
+		if isTotalRow(row) {
+			totals = append(totals, row)
+		} else {
+			data = append(data, row)
+		}
+	}
+	return data, totals
+}
+
+// sortRows returns the rows sorted by given column, starting from one. Zero
+// column keeps the report order, reversed if desc is set.
+func sortRows(rows [][]string, column int, desc bool) [][]string {
+	if column <= 0 {
+		if desc {
+			rows = slices.Clone(rows)
+			slices.Reverse(rows)
+		}
+		return rows
+	}
+	rows = slices.Clone(rows)
+	slices.SortStableFunc(rows, func(a, b []string) int {
+		c := compareCells(cell(a, column-1), cell(b, column-1))
+		if desc {
+			return -c
+		}
+		return c
+	})
+	return rows
+}
+
+// cell returns the value of given column in row, or empty string if the row
+// is too short.
+func cell(row []string, column int) string {
+	if column < 0 || column >= len(row) {
+		return ""
+	}
+	return row[column]
+}
+
+// compareCells compares table cell values. Durations and numbers are compared
+// by value, everything else as text. Dates and times in reports are formatted
+// so that they sort correctly as text.
+func compareCells(a, b string) int {
+	if da, err := time.ParseDuration(a); err == nil {
+		if db, err := time.ParseDuration(b); err == nil {
+			return cmp.Compare(da, db)
+		}
+	}
+	if na, err := strconv.ParseFloat(a, 64); err == nil {
+		if nb, err := strconv.ParseFloat(b, 64); err == nil {
+			return cmp.Compare(na, nb)
+		}
+	}
+	return strings.Compare(a, b)
+}
+
+// tableRowCapacity returns the number of data rows that fit into a table of
+// given height, with the given number of totals rows pinned at the bottom.
+// At least one row always fits.
+func tableRowCapacity(height, totals int) int {
+	// top border, header, header separator and bottom border.
+	return max(1, height-4-totals)
+}
+
+// scroll returns the cursor with the offset moved so that the selected row is
+// visible, when capacity rows fit on screen. Selected row is kept within the
+// given number of rows.
+func (c reportCursor) scroll(rows, capacity int) reportCursor {
+	c.row = max(0, min(c.row, rows-1))
+	switch {
+	case c.row < c.offset:
+		c.offset = c.row
+	case c.row >= c.offset+capacity:
+		c.offset = c.row - capacity + 1
+	}
+	c.offset = max(0, min(c.offset, rows-capacity))
+	return c
+}
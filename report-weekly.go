@@ -1,6 +1,7 @@
 package myhours
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -13,15 +14,18 @@ var reportWeekly = report{
 	title:   reportTitleWeekly,
 	dates:   reportDatesWeekly,
 	styles:  reportStyleWeekly,
-	mapper:  reportRecordsWeekly,
+	rows:    reportRowsWeekly,
 }
 
-func reportHeadersWeekly() []string {
+func reportHeadersWeekly(opts reportOptions) []string {
+	if opts.showBreaks {
+		return []string{"Weekday", "Date", "Duration", "Breaks"}
+	}
 	return []string{"Weekday", "Date", "Duration"}
 }
 
-func reportTitleWeekly(page int) string {
-	from, until := reportDatesWeekly(page)
+func reportTitleWeekly(now time.Time, page int) string {
+	from, until := reportDatesWeekly(now, page)
 	y, w := from.ISOWeek()
 	return fmt.Sprintf("Week %0d, %d (%s – %s)",
 		w,
@@ -31,11 +35,11 @@ func reportTitleWeekly(page int) string {
 	)
 }
 
-func reportDatesWeekly(offset int) (time.Time, time.Time) {
+func reportDatesWeekly(now time.Time, offset int) (time.Time, time.Time) {
 	if offset > 0 {
 		offset = 0
 	}
-	now := time.Now().AddDate(0, 0, offset*7) // week is always 7 days, so this still works.
+	now = now.AddDate(0, 0, offset*7) // week is always 7 days, so this still works.
 	wd := int(now.Weekday())
 	if wd == 0 {
 		wd = 7
@@ -53,91 +57,46 @@ func reportDatesWeekly(offset int) (time.Time, time.Time) {
 	}
 }
 
-func reportStyleWeekly(row, _ int, _ []string) lipgloss.Style {
+func reportStyleWeekly(s styles, row, _ int, _ []string) lipgloss.Style {
 	if row == 7 {
-		return styleTableSumRow
+		return s.tableSumRow
 	}
-	return styleTableCell
-}
-
-func reportRecordsWeekly(records []Record) [][]string {
-	var rows [][]string
-	for _, w := range newWeeklySummary(records) {
-		for _, d := range w.days {
-			rows = append(rows, []string{
-				d.weekDay.String()[:3],
-				d.date,
-				d.total.Truncate(time.Second).String(),
-			})
-		}
-		rows = append(rows, []string{"Total", "", w.total.Truncate(time.Second).String()})
-	}
-	if len(rows) == 0 {
-		rows = append(rows, []string{"NO DATA", "NO DATA", "NO DATA"})
-	}
-	return rows
-}
-
-type dailySummary struct {
-	date    string
-	weekDay time.Weekday
-	month   time.Month
-	total   time.Duration
-	notes   []string
-}
-
-type weeklySummary struct {
-	year     int
-	weekNo   int
-	startsOn time.Weekday
-	total    time.Duration
-	days     []dailySummary
+	return s.tableCell
 }
 
-func (s weeklySummary) dateRange() (string, string) {
-	if len(s.days) == 0 {
-		return "", ""
+// reportRowsWeekly has a row for each day of the week, from records aggregated
+// by day.
+func reportRowsWeekly(ctx context.Context, db RecordStore, from, before time.Time, categoryID int64, opts reportOptions) ([][]string, error) {
+	q := AggregateQuery{From: from, Before: before, CategoryID: categoryID, Period: PeriodDay}
+	days, err := db.Aggregate(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("db.Aggregate: %w", err)
 	}
-	if len(s.days) == 1 {
-		return s.days[0].date, s.days[0].date
+	if len(days) == 0 {
+		return [][]string{noDataRow(len(reportHeadersWeekly(opts)))}, nil
 	}
-	return s.days[0].date, s.days[len(s.days)-1].date
-}
-
-func newWeeklySummary(records []Record) []weeklySummary {
 	var (
-		weeks []weeklySummary
-		cw    *weeklySummary
+		rows  [][]string
+		total Aggregate
 	)
-	for _, record := range records {
-		// show dates in local time. They are stored in UTC.
-		start := record.Start.In(time.Local)
-		y, weekNo := start.In(time.Local).ISOWeek()
-		wd := int(start.Weekday())
-		if wd == 0 { // Sunday is zero. Horrible.
-			wd = 7
-		}
-		wd--
-		if cw == nil || cw.year != y || cw.weekNo != weekNo {
-			weeks = append(weeks, weeklySummary{year: y, weekNo: weekNo, startsOn: time.Monday})
-			cw = &weeks[len(weeks)-1]
-			// seed the days of the week to get a full week.
-			for i := range 7 {
-				d := start.AddDate(0, 0, -1*(wd-i))
-				cw.days = append(cw.days, dailySummary{
-					date:    d.Format(time.DateOnly),
-					weekDay: d.Weekday(),
-					month:   d.Month(),
-				})
-			}
+	bounds := q.Periods()
+	for _, start := range bounds[:len(bounds)-1] {
+		day := aggregate(days, start)
+		total.Duration += day.Duration
+		total.Breaks += day.Breaks
+		row := []string{
+			start.Weekday().String()[:3],
+			start.Format(time.DateOnly),
+			day.Duration.Truncate(time.Second).String(),
 		}
-		d := record.Duration()
-		cw.total += d
-		cd := &cw.days[wd]
-		cd.total += d
-		if record.Notes != "" {
-			cd.notes = append(cd.notes, record.Notes)
+		if opts.showBreaks {
+			row = append(row, day.Breaks.Truncate(time.Second).String())
 		}
+		rows = append(rows, row)
+	}
+	row := []string{"Total", "", total.Duration.Truncate(time.Second).String()}
+	if opts.showBreaks {
+		row = append(row, total.Breaks.Truncate(time.Second).String())
 	}
-	return weeks
+	return append(rows, row), nil
 }
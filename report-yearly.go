@@ -1,6 +1,8 @@
 package myhours
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"time"
 
@@ -13,23 +15,22 @@ var reportYearly = report{
 	title:   reportTitleYearly,
 	dates:   reportDatesYearly,
 	styles:  reportStyleYearly,
-	mapper:  reportRecordsYearly,
+	rows:    reportRowsYearly,
 }
 
-func reportHeadersYearly() []string {
+func reportHeadersYearly(reportOptions) []string {
 	return []string{"Month", "Active days", "Duration"}
 }
 
-func reportTitleYearly(page int) string {
-	from, _ := reportDatesYearly(page)
+func reportTitleYearly(now time.Time, page int) string {
+	from, _ := reportDatesYearly(now, page)
 	return "Year " + strconv.FormatInt(int64(from.Year()), 10)
 }
 
-func reportDatesYearly(offset int) (time.Time, time.Time) {
+func reportDatesYearly(now time.Time, offset int) (time.Time, time.Time) {
 	if offset > 0 {
 		offset = 0
 	}
-	now := time.Now()
 	y, _, _ := now.Date()
 	// first day of current year, minus as many years as offset says
 	from := time.Date(y, 1, 1, 0, 0, 0, 0, time.Local).AddDate(offset, 0, 0)
@@ -37,62 +38,45 @@ func reportDatesYearly(offset int) (time.Time, time.Time) {
 	return from, before
 }
 
-func reportStyleYearly(r, _ int, data []string) lipgloss.Style {
+func reportStyleYearly(s styles, r, _ int, data []string) lipgloss.Style {
 	if r < 0 || len(data) == 0 || data[0] == "" || data[0][0] != 'T' {
-		return styleTableCell
+		return s.tableCell
 	}
-	return styleTableSumRow
+	return s.tableSumRow
 }
 
-func reportRecordsYearly(records []Record) [][]string {
-	var rows [][]string
-	for _, y := range newYearlySummary(records) {
-		activeDaysTotal := 0
-		for _, m := range y.months {
-			activeDays := m.activeDays()
-			activeDaysTotal += activeDays
-			rows = append(rows, []string{
-				m.month.String(),
-				strconv.Itoa(activeDays),
-				m.total.Truncate(time.Second).String(),
-			})
-		}
-		rows = append(rows, []string{
-			"Total",
-			strconv.Itoa(activeDaysTotal),
-			y.total.Truncate(time.Second).String(),
-		})
+// reportRowsYearly has a row for each month of the year. Records are aggregated
+// by day, for counting the days with time spent.
+func reportRowsYearly(ctx context.Context, db RecordStore, from, before time.Time, categoryID int64, _ reportOptions) ([][]string, error) {
+	days, err := db.Aggregate(ctx, AggregateQuery{From: from, Before: before, CategoryID: categoryID, Period: PeriodDay})
+	if err != nil {
+		return nil, fmt.Errorf("db.Aggregate: %w", err)
 	}
-	if len(rows) == 0 {
-		rows = append(rows, []string{"NO DATA", "NO DATA", "NO DATA"})
+	if len(days) == 0 {
+		return [][]string{{"NO DATA", "NO DATA", "NO DATA"}}, nil
 	}
-	return rows
-}
-
-type yearlySummary struct {
-	year   int
-	months []monthlySummary
-	total  time.Duration
-}
-
-func newYearlySummary(records []Record) []yearlySummary {
 	var (
-		years []yearlySummary
-		cy    *yearlySummary
+		activeDays [12]int
+		totals     [12]time.Duration
+		rows       [][]string
+		active     int
+		total      time.Duration
 	)
-	for _, m := range newMonthlySummary(records) {
-		if cy == nil || cy.year != m.year {
-			years = append(years, yearlySummary{year: m.year})
-			cy = &years[len(years)-1]
-			for i := range 12 {
-				cy.months = append(cy.months, monthlySummary{
-					year:  m.year,
-					month: time.Month(i + 1),
-				})
-			}
+	for _, day := range days {
+		m := day.Start.Month() - 1
+		totals[m] += day.Duration
+		if day.Duration > 0 {
+			activeDays[m]++
 		}
-		cy.months[m.month-1] = m
-		cy.total += m.total
 	}
-	return years
+	for m := range 12 {
+		active += activeDays[m]
+		total += totals[m]
+		rows = append(rows, []string{
+			time.Month(m + 1).String(),
+			strconv.Itoa(activeDays[m]),
+			totals[m].Truncate(time.Second).String(),
+		})
+	}
+	return append(rows, []string{"Total", strconv.Itoa(active), total.Truncate(time.Second).String()}), nil
 }
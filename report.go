@@ -1,22 +1,75 @@
 package myhours
 
 import (
+	"context"
+	"fmt"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-type reportStyleFunc func(row, col int, rowData []string) lipgloss.Style
-type reportMapperFunc func([]Record) [][]string
-type reportDatesFunc func(int) (time.Time, time.Time)
-type reportTitleFunc func(int) string
-type reportHeaderFunc func() []string
+type reportStyleFunc func(s styles, row, col int, rowData []string) lipgloss.Style
+type reportRowsFunc func(ctx context.Context, db RecordStore, from, before time.Time, categoryID int64, opts reportOptions) ([][]string, error)
+type reportDatesFunc func(now time.Time, pageNo int) (time.Time, time.Time)
+type reportTitleFunc func(now time.Time, pageNo int) string
+type reportHeaderFunc func(reportOptions) []string
+
+// reportOptions contains the options that affect report contents.
+type reportOptions struct {
+	// showBreaks adds a column for time spent on breaks, if report supports it.
+	showBreaks bool
+}
+
+// noDataRow returns a row for reports with no data, with given number of columns.
+func noDataRow(columns int) []string {
+	row := make([]string, columns)
+	for i := range row {
+		row[i] = "NO DATA"
+	}
+	return row
+}
+
+// reportTable is the data of a report page, ready for rendering.
+type reportTable struct {
+	title   string
+	headers []string
+	rows    [][]string
+	style   reportStyleFunc
+}
 
 // report is a common spec for reports, defining the minimum requirements.
 type report struct {
 	headers reportHeaderFunc
-	mapper  reportMapperFunc
+	rows    reportRowsFunc
 	dates   reportDatesFunc
 	title   reportTitleFunc
 	styles  reportStyleFunc
 }
+
+// build the report table for given page, from finished records of given
+// category. The active record is left out, its time is shown by the timer.
+func (r report) build(ctx context.Context, db RecordStore, now time.Time, pageNo int, categoryID int64, opts reportOptions) (reportTable, error) {
+	from, before := r.dates(now, pageNo)
+	table := reportTable{
+		title:   r.title(now, pageNo),
+		headers: r.headers(opts),
+		style:   r.styles,
+	}
+	rows, err := r.rows(ctx, db, from, before, categoryID, opts)
+	if err != nil {
+		return table, fmt.Errorf("r.rows: %w", err)
+	}
+	table.rows = rows
+	return table, nil
+}
+
+// aggregate returns the aggregate of the period starting at start, or an empty
+// one if there were no records in the period.
+func aggregate(aggregates []Aggregate, start time.Time) Aggregate {
+	for _, a := range aggregates {
+		if a.Start.Equal(start) {
+			return a
+		}
+	}
+	return Aggregate{Start: start}
+}
@@ -0,0 +1,215 @@
+package myhours_test
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/msepp/myhours"
+	"github.com/msepp/myhours/database/memory"
+)
+
+// fuzzReport imports records decoded from fuzz data to the memory backend, and
+// runs check for the report page of each record, of all categories and of the
+// first one. Check is given the total duration and number of the records in
+// the page.
+func fuzzReport(f *testing.F, r myhours.Report, check func(t *testing.T, rows [][]string, from, before time.Time, total time.Duration, n int)) {
+	myhours.FuzzRecordSets(f, func(t *testing.T, _ time.Time, records []myhours.Record) {
+		ctx := context.Background()
+		db := memory.NewMemory()
+		if _, err := db.ImportRecords(ctx, records); err != nil {
+			t.Fatalf("ImportRecords() error = %v", err)
+		}
+		for _, record := range records {
+			from, before := r.Dates(record.Start, 0)
+			for _, categoryID := range []int64{0, 1} {
+				rows, err := r.Rows(ctx, db, from, before, categoryID, true)
+				if err != nil {
+					t.Fatalf("Rows() error = %v", err)
+				}
+				var (
+					total time.Duration
+					n     int
+				)
+				for _, record := range records {
+					if !record.Start.Before(from) && record.Start.Before(before) && (categoryID == 0 || record.CategoryID == categoryID) {
+						total += record.Duration()
+						n++
+					}
+				}
+				check(t, rows, from, before, total, n)
+			}
+		}
+	})
+}
+
+// parseDuration parses a duration column of a report row.
+func parseDuration(t *testing.T, s string) time.Duration {
+	t.Helper()
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		t.Fatalf("duration %q: %v", s, err)
+	}
+	return d
+}
+
+// checkNoData checks that a page without records has a single row without
+// data.
+func checkNoData(t *testing.T, rows [][]string) {
+	t.Helper()
+	if len(rows) != 1 || slices.ContainsFunc(rows[0], func(s string) bool { return s != "NO DATA" }) {
+		t.Errorf("rows = %v, want no data", rows)
+	}
+}
+
+// checkTotals checks that the duration and break columns of rows add up to
+// the total row, and the total duration to want.
+func checkTotals(t *testing.T, rows [][]string, want time.Duration) {
+	t.Helper()
+	var durations, breaks time.Duration
+	for _, row := range rows[:len(rows)-1] {
+		durations += parseDuration(t, row[2])
+		breaks += parseDuration(t, row[3])
+	}
+	total := rows[len(rows)-1]
+	if total[0] != "Total" || parseDuration(t, total[2]) != durations || parseDuration(t, total[3]) != breaks {
+		t.Errorf("total row %v, want durations %v and breaks %v", total, durations, breaks)
+	}
+	if durations != want {
+		t.Errorf("rows sum up to %v, want %v", durations, want)
+	}
+}
+
+func FuzzWeeklyReport(f *testing.F) {
+	fuzzReport(f, myhours.ReportWeekly, func(t *testing.T, rows [][]string, from, _ time.Time, total time.Duration, n int) {
+		if n == 0 {
+			checkNoData(t, rows)
+			return
+		}
+		if len(rows) != 8 {
+			t.Fatalf("%d rows, want 7 days and total", len(rows))
+		}
+		for i, row := range rows[:7] {
+			date := from.AddDate(0, 0, i)
+			if row[0] != date.Weekday().String()[:3] || row[1] != date.Format(time.DateOnly) || date.Weekday() != time.Weekday((i+1)%7) {
+				t.Errorf("day %d is %s %s, want %s", i, row[0], row[1], date.Format(time.DateOnly))
+			}
+		}
+		checkTotals(t, rows, total)
+	})
+}
+
+func FuzzMonthlyReport(f *testing.F) {
+	fuzzReport(f, myhours.ReportMonthly, func(t *testing.T, rows [][]string, from, before time.Time, total time.Duration, n int) {
+		if n == 0 {
+			checkNoData(t, rows)
+			return
+		}
+		// weeks follow each other from the first day of the month to the last.
+		next := from
+		for i, row := range rows[:len(rows)-1] {
+			start, end, _ := strings.Cut(row[1], " – ")
+			if start != next.Format(time.DateOnly) {
+				t.Errorf("week %d starts %s, want %s", i, start, next.Format(time.DateOnly))
+			}
+			if _, weekNo := next.ISOWeek(); row[0] != "W"+strconv.Itoa(weekNo) {
+				t.Errorf("week %d is %s, want W%d", i, row[0], weekNo)
+			}
+			if i > 0 && next.Weekday() != time.Monday {
+				t.Errorf("week %d starts on %s", i, next.Weekday())
+			}
+			last, err := time.ParseInLocation(time.DateOnly, end, time.Local)
+			if err != nil {
+				t.Fatalf("week %d end %q: %v", i, end, err)
+			}
+			next = last.AddDate(0, 0, 1)
+		}
+		if !next.Equal(before) {
+			t.Errorf("weeks end before %v, want before %v", next, before)
+		}
+		checkTotals(t, rows, total)
+	})
+}
+
+func FuzzYearlyReport(f *testing.F) {
+	fuzzReport(f, myhours.ReportYearly, func(t *testing.T, rows [][]string, from, _ time.Time, total time.Duration, n int) {
+		if n == 0 {
+			checkNoData(t, rows)
+			return
+		}
+		if len(rows) != 13 {
+			t.Fatalf("%d rows, want 12 months and total", len(rows))
+		}
+		var (
+			durations time.Duration
+			active    int
+		)
+		for i, row := range rows[:12] {
+			month := time.Month(i + 1)
+			days, err := strconv.Atoi(row[1])
+			if err != nil {
+				t.Fatalf("%s active days %q: %v", month, row[1], err)
+			}
+			if row[0] != month.String() || days < 0 || days > time.Date(from.Year(), month+1, 0, 0, 0, 0, 0, time.Local).Day() {
+				t.Errorf("month %d is %s with %d active days", i+1, row[0], days)
+			}
+			durations += parseDuration(t, row[2])
+			active += days
+		}
+		if want := []string{"Total", strconv.Itoa(active), durations.String()}; !reflect.DeepEqual(rows[12], want) {
+			t.Errorf("total row %v, want %v", rows[12], want)
+		}
+		if durations != total {
+			t.Errorf("rows sum up to %v, want %v", durations, total)
+		}
+	})
+}
+
+func Test_report_order(t *testing.T) {
+	var (
+		ctx     = context.Background()
+		start   = time.Date(2026, 1, 5, 9, 0, 0, 0, time.Local)
+		records = []myhours.Record{
+			{Start: start, End: start.Add(time.Hour), CategoryID: 1},
+			{Start: start.Add(2 * time.Hour), End: start.Add(3 * time.Hour), CategoryID: 2},
+			{Start: start.AddDate(0, 0, 1), End: start.AddDate(0, 0, 1).Add(time.Hour), CategoryID: 1},
+		}
+		ordered, reversed = memory.NewMemory(), memory.NewMemory()
+	)
+	if _, err := ordered.ImportRecords(ctx, records); err != nil {
+		t.Fatalf("ImportRecords() error = %v", err)
+	}
+	slices.Reverse(records)
+	if _, err := reversed.ImportRecords(ctx, records); err != nil {
+		t.Fatalf("ImportRecords() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		report myhours.Report
+	}{
+		{name: "weekly", report: myhours.ReportWeekly},
+		{name: "monthly", report: myhours.ReportMonthly},
+		{name: "yearly", report: myhours.ReportYearly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, before := tt.report.Dates(start, 0)
+			want, err := tt.report.Rows(ctx, ordered, from, before, 0, true)
+			if err != nil {
+				t.Fatalf("Rows() error = %v", err)
+			}
+			// records inserted out of order give the same report.
+			got, err := tt.report.Rows(ctx, reversed, from, before, 0, true)
+			if err != nil {
+				t.Fatalf("Rows() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Rows() = %v, want %v", got, want)
+			}
+		})
+	}
+}